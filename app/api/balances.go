@@ -0,0 +1,114 @@
+package api
+
+import (
+	"go-evm-indexer/models"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// handleAccount handles routes of `/accounts/{address}/...`
+func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/balance"):
+		s.handleBalance(w, r)
+	case strings.HasSuffix(r.URL.Path, "/tokens"):
+		s.handleTokenBalances(w, r)
+	default:
+		s.handleTokenTransfersByHolder(w, r)
+	}
+}
+
+// handleBalance handles `GET /accounts/{address}/balance?block=`,
+// it returns the latest snapshot of balance and nonce at or before block, the latest one is returned if block is empty
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/")
+	if len(parts) != 2 || parts[1] != "balance" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if !common.IsHexAddress(parts[0]) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	var number uint64 = math.MaxInt64
+	if value := r.URL.Query().Get("block"); value != "" {
+		var err error
+		if number, err = strconv.ParseUint(value, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid block number")
+			return
+		}
+	}
+
+	balance, err := s.balancesRepo.FindBalanceAt(r.Context(), common.HexToAddress(parts[0]), number)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find balance")
+		return
+	}
+
+	if balance == nil {
+		writeError(w, http.StatusNotFound, "balance not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balance)
+}
+
+// handleTokenBalances handles `GET /accounts/{address}/tokens?cursor=&limit=`,
+// token balances are sorted by token ascending and cursor is token address that page starts after
+func (s *Server) handleTokenBalances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/")
+	if len(parts) != 2 || parts[1] != "tokens" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if !common.IsHexAddress(parts[0]) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	cursor := r.URL.Query().Get("cursor")
+	if cursor != "" && !common.IsHexAddress(cursor) {
+		writeError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+
+	balances, err := s.tokenBalancesRepo.FindTokenBalancesByHolder(r.Context(), common.HexToAddress(parts[0]), cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find token balances")
+		return
+	}
+
+	res := &page{Data: balances}
+	if balances == nil {
+		res.Data = []models.TokenBalance{}
+	}
+
+	if int64(len(balances)) == limit {
+		res.Next = balances[len(balances)-1].Token
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
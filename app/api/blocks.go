@@ -0,0 +1,119 @@
+package api
+
+import (
+	"go-evm-indexer/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// handleBlocks handles `GET /blocks?cursor=&limit=`
+//
+// Blocks are sorted by number descending, cursor is the block number that page starts from
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	var cursor uint64
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		number, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		cursor = number
+	} else {
+		latest, err := s.blocksRepo.FindLastestBlock(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to find latest block")
+			return
+		}
+
+		if latest == nil {
+			writeJSON(w, http.StatusOK, &page{Data: []models.Block{}})
+			return
+		}
+		cursor = latest.Number
+	}
+
+	blocks, err := s.blocksRepo.FindBlocksByCursor(r.Context(), cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find blocks")
+		return
+	}
+
+	res := &page{Data: blocks}
+	if blocks == nil {
+		res.Data = []models.Block{}
+	}
+
+	if int64(len(blocks)) == limit && blocks[len(blocks)-1].Number > 0 {
+		res.Next = strconv.FormatUint(blocks[len(blocks)-1].Number-1, 10)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleBlock handles `GET /blocks/{number|hash}` and `GET /blocks/{hash}/events`
+func (s *Server) handleBlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/blocks/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		s.getBlock(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "events":
+		s.getEventsByBlockHash(w, r, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) getBlock(w http.ResponseWriter, r *http.Request, id string) {
+	var (
+		block *models.Block
+		err   error
+	)
+
+	if strings.HasPrefix(id, "0x") {
+		if !isHash(id) {
+			writeError(w, http.StatusBadRequest, "invalid block hash")
+			return
+		}
+		block, err = s.blocksRepo.FindBlockByHash(r.Context(), common.HexToHash(id))
+	} else {
+		number, parseErr := strconv.ParseUint(id, 10, 64)
+		if parseErr != nil {
+			writeError(w, http.StatusBadRequest, "invalid block number")
+			return
+		}
+		block, err = s.blocksRepo.FindBlockByNumber(r.Context(), number)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find block")
+		return
+	}
+
+	// Incomplete blocks are being processed, they are not exposed
+	if block == nil || !block.IsDone {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, block)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// getEventsByBlockHash handles `GET /blocks/{hash}/events?cursor=&limit=`
+//
+// Events are sorted by log index ascending, cursor is the log index that page starts from
+func (s *Server) getEventsByBlockHash(w http.ResponseWriter, r *http.Request, hash string) {
+	if !isHash(hash) {
+		writeError(w, http.StatusBadRequest, "invalid block hash")
+		return
+	}
+
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	var cursor uint
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		index, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		cursor = uint(index)
+	}
+
+	events, err := s.eventsRepo.FindEventsByBlockHashByCursor(r.Context(), common.HexToHash(hash), cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find events")
+		return
+	}
+
+	res := &page{Data: events}
+	if events == nil {
+		res.Data = []models.Event{}
+	}
+
+	if int64(len(events)) == limit {
+		res.Next = strconv.FormatUint(uint64(events[len(events)-1].Index+1), 10)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleEvents handles `GET /events?address=&topic0=&topic1=&topic2=&topic3=&fromBlock=&toBlock=&cursor=&limit=`
+//
+// `address` and `topic{0-3}` can be multiple values separated by comma, it matches one of them.
+// Events are sorted by block number and index ascending, cursor is `{block number}-{index}` of event that page starts from
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	filter := &repository.EventFilter{
+		Limit: limit,
+	}
+
+	for _, v := range []struct {
+		key string
+		out **uint64
+	}{
+		{key: "fromBlock", out: &filter.FromBlock},
+		{key: "toBlock", out: &filter.ToBlock},
+	} {
+		if value := query.Get(v.key); value != "" {
+			number, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid "+v.key)
+				return
+			}
+			*v.out = &number
+		}
+	}
+
+	for _, address := range splitValues(query.Get("address")) {
+		if !common.IsHexAddress(address) {
+			writeError(w, http.StatusBadRequest, "invalid address")
+			return
+		}
+		filter.Addresses = append(filter.Addresses, common.HexToAddress(address))
+	}
+
+	for i := 0; i < 4; i++ {
+		var topics []common.Hash
+		for _, topic := range splitValues(query.Get(fmt.Sprintf("topic%d", i))) {
+			if !isHash(topic) {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid topic%d", i))
+				return
+			}
+			topics = append(topics, common.HexToHash(topic))
+		}
+		filter.Topics = append(filter.Topics, topics)
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := parseEventCursor(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		filter.Cursor = cursor
+	}
+
+	events, err := s.eventsRepo.FindEvents(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find events")
+		return
+	}
+
+	res := &page{Data: events}
+	if events == nil {
+		res.Data = []models.Event{}
+	}
+
+	if int64(len(events)) == limit {
+		last := events[len(events)-1]
+		res.Next = fmt.Sprintf("%d-%d", last.BlockNumber, last.Index+1)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func parseEventCursor(value string) (*repository.EventCursor, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	blockNumber, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.EventCursor{
+		BlockNumber: blockNumber,
+		Index:       uint(index),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Requeuer puts failed block to process again, it returns false if block is not in failed blocks
+type Requeuer interface {
+	Requeue(ctx context.Context, number uint64) (bool, error)
+}
+
+// handleFailedBlocks handles `GET /failed-blocks?cursor=&limit=`,
+// failed blocks are sorted by number ascending and cursor is block number that page starts from
+func (s *Server) handleFailedBlocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	var cursor uint64
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(value, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	blocks, err := s.failedBlocksRepo.FindFailedBlocks(r.Context(), cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find failed blocks")
+		return
+	}
+
+	res := &page{Data: blocks}
+	if blocks == nil {
+		res.Data = []models.FailedBlock{}
+	}
+
+	if int64(len(blocks)) == limit {
+		res.Next = strconv.FormatUint(blocks[len(blocks)-1].Number+1, 10)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleRequeueFailedBlock handles `POST /failed-blocks/{number}/requeue`
+func (s *Server) handleRequeueFailedBlock(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/failed-blocks/"), "/")
+	if len(parts) != 2 || parts[1] != "requeue" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid block number")
+		return
+	}
+
+	if s.requeuer == nil {
+		writeError(w, http.StatusServiceUnavailable, "indexer is not running")
+		return
+	}
+
+	found, err := s.requeuer.Requeue(r.Context(), number)
+	if err != nil {
+		log.Printf("❌ failed to requeue failed block [ block : %d ] : %s\n", number, err.Error())
+		writeError(w, http.StatusInternalServerError, "failed to requeue failed block")
+		return
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "failed block not found")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]uint64{"number": number})
+}
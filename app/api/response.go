@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// page response of list that using cursor-based pagination,
+// `Next` is cursor of next page and it will be empty on the last page
+type page struct {
+	Data interface{} `json:"data"`
+	Next string      `json:"next,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("❌ failed to write api response : %s\n", err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Error: message})
+}
+
+// parseLimit function that read `limit` query of request, it returns default limit if query is empty
+func parseLimit(r *http.Request) (int64, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultPageLimit, true
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, false
+	}
+
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return limit, true
+}
+
+// isHash function that check input is a hex string of 32 bytes hash
+func isHash(value string) bool {
+	b, err := hexutil.Decode(value)
+	return err == nil && len(b) == common.HashLength
+}
+
+// splitValues function that split comma separated values of query
+func splitValues(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	values := strings.Split(value, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+
+	return values
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"go-evm-indexer/app/graphql"
+	"go-evm-indexer/config"
+	"go-evm-indexer/repository"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultPageLimit and maxPageLimit are number of items of a page when using cursor-based pagination
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type Server struct {
+	blocksRepo               repository.IBlocksRepository
+	transactionsRepo         repository.ITransactionsRepository
+	eventsRepo               repository.IEventsRepository
+	tokenTransfersRepo       repository.ITokenTransfersRepository
+	internalTransactionsRepo repository.IInternalTransactionsRepository
+	failedBlocksRepo         repository.IFailedBlocksRepository
+	webhookSubscriptionsRepo repository.IWebhookSubscriptionsRepository
+	balancesRepo             repository.IBalancesRepository
+	tokenBalancesRepo        repository.ITokenBalancesRepository
+
+	requeuer Requeuer
+	// adminToken is bearer token of admin endpoints, admin endpoints are disabled when it is empty
+	adminToken string
+
+	server *http.Server
+}
+
+func New(
+	addr string,
+
+	blocksRepo repository.IBlocksRepository,
+	transactionsRepo repository.ITransactionsRepository,
+	eventsRepo repository.IEventsRepository,
+	tokenTransfersRepo repository.ITokenTransfersRepository,
+	internalTransactionsRepo repository.IInternalTransactionsRepository,
+	failedBlocksRepo repository.IFailedBlocksRepository,
+	webhookSubscriptionsRepo repository.IWebhookSubscriptionsRepository,
+	balancesRepo repository.IBalancesRepository,
+	tokenBalancesRepo repository.ITokenBalancesRepository,
+
+	requeuer Requeuer,
+) *Server {
+	s := &Server{
+		blocksRepo:               blocksRepo,
+		transactionsRepo:         transactionsRepo,
+		eventsRepo:               eventsRepo,
+		tokenTransfersRepo:       tokenTransfersRepo,
+		internalTransactionsRepo: internalTransactionsRepo,
+		failedBlocksRepo:         failedBlocksRepo,
+		webhookSubscriptionsRepo: webhookSubscriptionsRepo,
+		balancesRepo:             balancesRepo,
+		tokenBalancesRepo:        tokenBalancesRepo,
+
+		requeuer:   requeuer,
+		adminToken: config.Get().AdminToken,
+	}
+
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      s.routes(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	return s
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks", s.handleBlocks)
+	mux.HandleFunc("/blocks/", s.handleBlock)
+	mux.HandleFunc("/transactions/", s.handleTransaction)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/tokens/", s.handleTokenTransfersByToken)
+	mux.HandleFunc("/accounts/", s.handleAccount)
+	mux.HandleFunc("/failed-blocks", s.admin(s.handleFailedBlocks))
+	mux.HandleFunc("/failed-blocks/", s.admin(s.handleRequeueFailedBlock))
+	mux.HandleFunc("/webhooks", s.admin(s.handleWebhooks))
+	mux.HandleFunc("/webhooks/", s.admin(s.handleWebhook))
+	mux.Handle("/graphql", graphql.New(s.blocksRepo, s.transactionsRepo, s.eventsRepo, config.Get()))
+
+	return mux
+}
+
+// admin function that wraps handler of admin endpoint, request must have bearer token of `ADMIN_TOKEN`
+func (s *Server) admin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			writeError(w, http.StatusForbidden, "admin endpoints are disabled")
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	value := r.Header.Get("Authorization")
+	if !strings.HasPrefix(value, prefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(value, prefix), true
+}
+
+// Start function that serves http server in background
+func (s *Server) Start() {
+	log.Printf("starting api server on %s\n", s.server.Addr)
+
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ api server stopped : %s\n", err.Error())
+		}
+	}()
+}
+
+// Shutdown function that stops http server after all active requests are completed
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
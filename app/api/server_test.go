@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "disabled without token", token: "", authorization: "Bearer ", want: http.StatusForbidden},
+		{name: "missing authorization", token: "secret", authorization: "", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", authorization: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "token without scheme", token: "secret", authorization: "secret", want: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", authorization: "Bearer secret", want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{adminToken: tt.token}
+			handler := s.admin(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("got status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	handler := (&Server{adminToken: "secret"}).routes()
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodGet, path: "/webhooks"},
+		{method: http.MethodPost, path: "/webhooks"},
+		{method: http.MethodDelete, path: "/webhooks/1"},
+		{method: http.MethodGet, path: "/failed-blocks"},
+		{method: http.MethodPost, path: "/failed-blocks/1/requeue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("got status %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// handleTokenTransfersByToken handles `GET /tokens/{address}/transfers?cursor=&limit=`
+func (s *Server) handleTokenTransfersByToken(w http.ResponseWriter, r *http.Request) {
+	s.handleTokenTransfers(w, r, "/tokens/", s.tokenTransfersRepo.FindTokenTransfersByToken)
+}
+
+// handleTokenTransfersByHolder handles `GET /accounts/{address}/transfers?cursor=&limit=`
+func (s *Server) handleTokenTransfersByHolder(w http.ResponseWriter, r *http.Request) {
+	s.handleTokenTransfers(w, r, "/accounts/", s.tokenTransfersRepo.FindTokenTransfersByHolder)
+}
+
+// handleTokenTransfers handles token transfers of address,
+// token transfers are sorted by block number, log index and batch index ascending,
+// cursor is `{block number}-{log index}-{batch index}` of token transfer that page starts from
+func (s *Server) handleTokenTransfers(
+	w http.ResponseWriter,
+	r *http.Request,
+	prefix string,
+	find func(ctx context.Context, address common.Address, cursor *repository.TokenTransferCursor, limit int64) ([]models.TokenTransfer, error),
+) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if len(parts) != 2 || parts[1] != "transfers" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if !common.IsHexAddress(parts[0]) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	var cursor *repository.TokenTransferCursor
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		var err error
+		if cursor, err = parseTokenTransferCursor(value); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	transfers, err := find(r.Context(), common.HexToAddress(parts[0]), cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find token transfers")
+		return
+	}
+
+	res := &page{Data: transfers}
+	if transfers == nil {
+		res.Data = []models.TokenTransfer{}
+	}
+
+	if int64(len(transfers)) == limit {
+		last := transfers[len(transfers)-1]
+		res.Next = fmt.Sprintf("%d-%d-%d", last.BlockNumber, last.LogIndex, last.BatchIndex+1)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func parseTokenTransferCursor(value string) (*repository.TokenTransferCursor, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		number, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+
+	return &repository.TokenTransferCursor{
+		BlockNumber: numbers[0],
+		LogIndex:    uint(numbers[1]),
+		BatchIndex:  uint(numbers[2]),
+	}, nil
+}
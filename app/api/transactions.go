@@ -0,0 +1,63 @@
+package api
+
+import (
+	"go-evm-indexer/models"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// handleTransaction handles `GET /transactions/{hash}` and `GET /transactions/{hash}/internal`
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/transactions/"), "/")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "internal") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	hash := parts[0]
+	if !isHash(hash) {
+		writeError(w, http.StatusBadRequest, "invalid transaction hash")
+		return
+	}
+
+	if len(parts) == 2 {
+		s.handleInternalTransactions(w, r, common.HexToHash(hash))
+		return
+	}
+
+	tx, err := s.transactionsRepo.FindTransactionByHash(r.Context(), common.HexToHash(hash))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find transaction")
+		return
+	}
+
+	if tx == nil {
+		writeError(w, http.StatusNotFound, "transaction not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// handleInternalTransactions handles internal transactions of transaction sorted by index,
+// it is empty when tracing is disabled
+func (s *Server) handleInternalTransactions(w http.ResponseWriter, r *http.Request, hash common.Hash) {
+	internalTxs, err := s.internalTransactionsRepo.FindInternalTransactionsByTransactionHash(r.Context(), hash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find internal transactions")
+		return
+	}
+
+	if internalTxs == nil {
+		internalTxs = []models.InternalTransaction{}
+	}
+
+	writeJSON(w, http.StatusOK, internalTxs)
+}
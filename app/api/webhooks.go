@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"go-evm-indexer/app/webhook"
+	"go-evm-indexer/models"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxBodySize maximum size of request body
+const maxBodySize = 1 << 20
+
+type createWebhookRequest struct {
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	Addresses []string `json:"addresses"`
+	Topics    []string `json:"topics"`
+}
+
+// handleWebhooks handles `GET /webhooks` and `POST /webhooks`,
+// secret of subscription is returned only when it is created
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		subscriptions, err := s.webhookSubscriptionsRepo.FindWebhookSubscriptions(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to find webhook subscriptions")
+			return
+		}
+
+		if subscriptions == nil {
+			subscriptions = []models.WebhookSubscription{}
+		}
+		for i := range subscriptions {
+			subscriptions[i].Secret = ""
+		}
+
+		writeJSON(w, http.StatusOK, &page{Data: subscriptions})
+	case http.MethodPost:
+		var req createWebhookRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		subscription, err := webhook.NewSubscription(req.URL, req.Secret, req.Addresses, req.Topics)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := s.webhookSubscriptionsRepo.AddWebhookSubscription(r.Context(), subscription); err != nil {
+			log.Printf("❌ failed to add webhook subscription to db : %s\n", err.Error())
+			writeError(w, http.StatusInternalServerError, "failed to add webhook subscription")
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, subscription)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleWebhook handles `DELETE /webhooks/{id}`
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/webhooks/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	found, err := s.webhookSubscriptionsRepo.DeleteWebhookSubscription(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete webhook subscription")
+		return
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "webhook subscription not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
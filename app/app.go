@@ -1,24 +1,169 @@
 package app
 
 import (
+	"context"
+	"go-evm-indexer/app/api"
 	"go-evm-indexer/app/block"
+	"go-evm-indexer/app/decoder"
+	"go-evm-indexer/app/jsonrpc"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/app/sink"
+	"go-evm-indexer/app/webhook"
 	"go-evm-indexer/config"
-	"go-evm-indexer/repository"
+	"go-evm-indexer/entity"
+	"log"
+	"time"
 )
 
-func Run() {
-	blockChainNodeConn, mongoClient := bootstrap()
-	blocksRepo := repository.NewBlocksRepository(mongoClient.Database(config.Get().MongoDBName))
-	transactionsRepo := repository.NewTransactionsRepository(mongoClient.Database(config.Get().MongoDBName))
-	eventsRepo := repository.NewEventsRepository(mongoClient.Database(config.Get().MongoDBName))
+// shutdownTimeout is maximum duration to wait for servers and database to be closed
+const shutdownTimeout = 10 * time.Second
 
-	rollback := repository.NewRollback(mongoClient)
+// Run function that runs indexer until context is canceled,
+// it returns after all running jobs are completed and all connections are closed
+func Run(ctx context.Context) error {
+	blockChainNodeConn, repos := bootstrap()
+	defer shutdown(blockChainNodeConn, repos)
 
-	blk := block.New(blockChainNodeConn, blocksRepo, transactionsRepo, eventsRepo, rollback)
+	if config.Get().MetricsAddr != "" {
+		shutdown := metrics.Start(config.Get().MetricsAddr)
+		defer stopServer("metrics", shutdown)
+	}
+
+	snk := newSink()
+	defer closeSink(snk)
+
+	stopRelay := startRelay(ctx, repos, snk)
+	defer stopRelay()
+
+	blk := newBlock(blockChainNodeConn, repos, snk != nil)
+
+	if config.Get().APIAddr != "" {
+		server := api.New(config.Get().APIAddr, repos.blocks, repos.transactions, repos.events, repos.tokenTransfers, repos.internalTransactions, repos.failedBlocks, repos.webhookSubscriptions, repos.balances, repos.tokenBalances, blk)
+		server.Start()
+		defer stopServer("api", server.Shutdown)
+	}
+
+	if config.Get().JSONRPCAddr != "" {
+		server := jsonrpc.New(config.Get().JSONRPCAddr, repos.blocks, repos.transactions, repos.events, repos.syncState, blockChainNodeConn.RPC, config.Get().Filtered())
+		server.Start()
+		defer stopServer("json-rpc", server.Shutdown)
+	}
+
+	dispatcher := webhook.NewDispatcher(repos.webhookSubscriptions, repos.webhookDeliveries)
+	dispatcher.Start(ctx)
+	defer dispatcher.Wait()
+
+	blockChainNodeConn.RPC.Start(ctx)
+	if blockChainNodeConn.Websocket != nil {
+		blockChainNodeConn.Websocket.Start(ctx)
+	}
+
+	if blockChainNodeConn.Websocket == nil {
+		return blk.ListenToNewBlocks(ctx, block.WithListenerOptionsRPCSubscribe)
+	}
+
+	return blk.ListenToNewBlocks(ctx)
+}
+
+// Migrate function that re-indexes blocks that were stored by older schema version and then returns
+func Migrate(ctx context.Context) error {
+	blockChainNodeConn, repos := bootstrap()
+	defer shutdown(blockChainNodeConn, repos)
+
+	snk := newSink()
+	defer closeSink(snk)
+
+	stopRelay := startRelay(ctx, repos, snk)
+	defer stopRelay()
+
+	blockChainNodeConn.RPC.Start(ctx)
+
+	return newBlock(blockChainNodeConn, repos, snk != nil).MigrateBlocks(ctx)
+}
+
+// Backfill function that indexes blocks in range and then returns
+func Backfill(ctx context.Context, from, to uint64) error {
+	blockChainNodeConn, repos := bootstrap()
+	defer shutdown(blockChainNodeConn, repos)
+
+	snk := newSink()
+	defer closeSink(snk)
+
+	stopRelay := startRelay(ctx, repos, snk)
+	defer stopRelay()
+
+	blockChainNodeConn.RPC.Start(ctx)
+
+	return newBlock(blockChainNodeConn, repos, snk != nil).Backfill(ctx, from, to)
+}
+
+// RebuildTokenBalances function that regenerates token balances from stored token transfers and then returns
+func RebuildTokenBalances(ctx context.Context) error {
+	blockChainNodeConn, repos := bootstrap()
+	defer shutdown(blockChainNodeConn, repos)
+
+	return newBlock(blockChainNodeConn, repos, false).RebuildTokenBalances(ctx)
+}
+
+func newBlock(blockChainNodeConn *entity.BlockChainNodeConnection, repos *repositories, publish bool) *block.Block {
+	registry := decoder.New()
+	if config.Get().ABIDir != "" {
+		var err error
+		registry, err = decoder.LoadDir(config.Get().ABIDir)
+		if err != nil {
+			log.Fatalf("❌ failed to load abi registry : %s\n", err.Error())
+		}
+	}
+
+	return block.New(blockChainNodeConn, repos.blocks, repos.transactions, repos.events, repos.tokenTransfers, repos.internalTransactions, repos.failedBlocks, repos.syncState, repos.webhookSubscriptions, repos.webhookDeliveries, repos.balances, repos.tokenBalances, repos.sinkMessages, repos.rollback, registry, publish)
+}
+
+// shutdown function that closes connections of database and blockchain node
+func shutdown(blockChainNodeConn *entity.BlockChainNodeConnection, repos *repositories) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := repos.close(ctx); err != nil {
+		log.Printf("❌ failed to close db connection : %s\n", err.Error())
+	}
+	blockChainNodeConn.Close()
+}
+
+// startRelay function that publishes stored messages to sink in background, it returns function
+// that stops relay and waits for it. Messages that are left in outbox are published when relay is started again
+func startRelay(ctx context.Context, repos *repositories, snk sink.Sink) func() {
+	if snk == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	relay := sink.NewRelay(repos.sinkMessages, snk)
+	relay.Start(ctx)
+
+	return func() {
+		cancel()
+		relay.Wait()
+	}
+}
+
+// closeSink function that closes sink after all messages are published
+func closeSink(snk sink.Sink) {
+	if snk == nil {
+		return
+	}
+
+	if err := snk.Close(); err != nil {
+		log.Printf("❌ failed to close sink : %s\n", err.Error())
+	}
+}
+
+// stopServer function that shutdowns server with timeout
+func stopServer(name string, shutdown func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	if config.Get().WebsocketURL == "" {
-		blk.ListenToNewBlocks(block.WithListenerOptionsRPCSubscribe)
-	} else {
-		blk.ListenToNewBlocks()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("❌ failed to shutdown %s server : %s\n", name, err.Error())
 	}
 }
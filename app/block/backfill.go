@@ -0,0 +1,154 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/config"
+	"go-evm-indexer/entity"
+	"log"
+	"math"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/gammazero/workerpool"
+)
+
+// backfillProgressInterval is interval of reporting progress of backfill
+const backfillProgressInterval = 10 * time.Second
+
+// backfillMaxAttempts is maximum attempts of failed block in backfill when maximum retries is not set,
+// backfill has to return so failed block is not retried forever
+const backfillMaxAttempts = 5
+
+// Backfill function that indexes all blocks in range and then returns, blocks that already indexed are skipped
+// so it can be resumed by running again with the same range after interruption
+func (b *Block) Backfill(ctx context.Context, from, to uint64) error {
+	if to < from {
+		return fmt.Errorf("invalid range [ from : %d ] [ to : %d ]", from, to)
+	}
+
+	if err := b.deleteIncompleteBlocks(ctx); err != nil {
+		return err
+	}
+	if err := b.prepareSyncState(ctx); err != nil {
+		return err
+	}
+
+	log.Printf("starting backfill from [ block : %d ] to [ block : %d ]\n", from, to)
+
+	var (
+		total = float64(to-from) + 1
+		// completed is number of blocks in range that jobs are completed or that were indexed already
+		completed uint64
+	)
+
+	progressCtx, stopProgress := context.WithCancel(ctx)
+	defer stopProgress()
+
+	go func() {
+		for wait(progressCtx, backfillProgressInterval) {
+			n := atomic.LoadUint64(&completed)
+			log.Printf("backfill progress [ blocks : %d ] [ %.2f%% ]\n", n, float64(n)*100/total)
+		}
+	}()
+
+	queueCtx, stopQueue := context.WithCancel(ctx)
+	defer stopQueue()
+	b.queue.Start(queueCtx)
+
+	// Blocks are fetched the same way as sync, jobs are also counted to report progress
+	b.sync(ctx, from, to, func(wp *workerpool.WorkerPool, j *entity.Job) {
+		wp.Submit(func() {
+			// Job that has not been started yet is skipped when shutting down and is not counted
+			if ctx.Err() != nil {
+				return
+			}
+
+			b.runJob(ctx, j.BlockNumber)
+			atomic.AddUint64(&completed, 1)
+		})
+	}, func(n uint64) {
+		atomic.AddUint64(&completed, n)
+	})
+	stopProgress()
+
+	b.retryFailedBlocks(ctx)
+
+	if ctx.Err() != nil {
+		log.Printf("backfill stopped after [ blocks : %d ], run again with the same range to resume\n", atomic.LoadUint64(&completed))
+		return nil
+	}
+
+	missing, err := b.countMissingBlocks(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	if missing > 0 {
+		log.Printf("⚠️ backfill completed with [ %d ] missing blocks, run again with the same range to retry\n", missing)
+		return nil
+	}
+
+	log.Printf("✅ backfill completed from [ block : %d ] to [ block : %d ]\n", from, to)
+
+	return nil
+}
+
+// retryFailedBlocks function that retries block numbers that have been put back to queue by failed jobs,
+// it returns when all of them are done or given up, or context is canceled.
+//
+// Block number is given up after maximum retries, or after backfill maximum attempts when maximum retries is not set,
+// given up block number is left missing so it is retried by running backfill again
+func (b *Block) retryFailedBlocks(ctx context.Context) {
+	if b.queue.Pending() == 0 {
+		return
+	}
+
+	log.Printf("retrying [ %d ] failed blocks\n", b.queue.Pending())
+
+	// Block numbers of backfill are confirmed already, those can be handed out without waiting for new blocks
+	b.queue.SetLatestBlockNumber(math.MaxUint64)
+
+	maxAttempts := config.Get().MaxRetries
+	if maxAttempts <= 0 {
+		maxAttempts = backfillMaxAttempts
+	}
+
+	wp := workerpool.New(runtime.NumCPU() * int(config.Get().Concurrency))
+	defer wp.StopWait()
+
+	for b.queue.Pending() > 0 {
+		for _, number := range b.queue.DropFailed(maxAttempts) {
+			log.Printf("⚠️ [ block : %d ] is given up after [ %d ] attempts\n", number, maxAttempts)
+		}
+
+		b.confirmBlocks(ctx, wp)
+
+		if !wait(ctx, time.Second) {
+			return
+		}
+	}
+}
+
+// countMissingBlocks function that counts blocks in range that are not indexed
+func (b *Block) countMissingBlocks(ctx context.Context, from, to uint64) (uint64, error) {
+	var missing uint64
+
+	for i := from; ; i += syncBatchSize {
+		toExpected := i + syncBatchSize - 1
+		if toExpected > to || toExpected < i {
+			toExpected = to
+		}
+
+		numbers, err := b.findMissingBlocks(ctx, i, toExpected)
+		if err != nil {
+			return 0, err
+		}
+		missing += uint64(len(numbers))
+
+		if toExpected == to {
+			return missing, nil
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// touchedAddresses function that returns addresses that are touched in block sorted by address,
+// those are miner, sender, recipient and created contract of transactions and sender and recipient of internal calls
+// that were not reverted
+func touchedAddresses(block *types.Block, bundledTxs []*models.BundledTransaction) []string {
+	var (
+		zero    = common.Address{}.Hex()
+		touched = map[string]bool{block.Coinbase().Hex(): true}
+	)
+
+	add := func(address string) {
+		if address != "" && address != zero {
+			touched[address] = true
+		}
+	}
+
+	for _, bundledTx := range bundledTxs {
+		add(bundledTx.Transaction.From)
+		add(bundledTx.Transaction.To)
+		add(bundledTx.Transaction.Contract)
+
+		for _, internalTx := range bundledTx.InternalTransactions {
+			if internalTx.Reverted {
+				continue
+			}
+
+			add(internalTx.From)
+			add(internalTx.To)
+		}
+	}
+
+	addresses := make([]string, 0, len(touched))
+	for address := range touched {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// fetchBalances function that fetches balance and nonce of touched addresses after block by
+// batch requests of `eth_getBalance` and `eth_getTransactionCount`
+//
+// State of block is pruned by non-archive node after a while, so old blocks can be fetched only from archive node
+func (b *Block) fetchBalances(ctx context.Context, block *types.Block, bundledTxs []*models.BundledTransaction) ([]*models.Balance, error) {
+	var (
+		addresses = touchedAddresses(block, bundledTxs)
+		number    = hexutil.EncodeBig(block.Number())
+		balances  = make([]hexutil.Big, len(addresses))
+		nonces    = make([]hexutil.Uint64, len(addresses))
+		batchSize = config.Get().RPCBatchSize
+	)
+
+	// Each address needs two requests
+	if batchSize <= 1 {
+		batchSize = len(addresses)
+	} else {
+		batchSize /= 2
+	}
+
+	for from := 0; from < len(addresses); from += batchSize {
+		to := from + batchSize
+		if to > len(addresses) {
+			to = len(addresses)
+		}
+
+		batch := make([]rpc.BatchElem, 0, 2*(to-from))
+		for i := from; i < to; i++ {
+			batch = append(batch,
+				rpc.BatchElem{
+					Method: "eth_getBalance",
+					Args:   []interface{}{addresses[i], number},
+					Result: &balances[i],
+				},
+				rpc.BatchElem{
+					Method: "eth_getTransactionCount",
+					Args:   []interface{}{addresses[i], number},
+					Result: &nonces[i],
+				},
+			)
+		}
+
+		start := time.Now()
+		err := b.blockChainNodeConn.RPC.BatchCallContext(ctx, batch)
+		metrics.ObserveRPC("batch_eth_getBalance", start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch balances [ block : %d ] : %s", block.NumberU64(), err.Error())
+		}
+
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to fetch balances [ block : %d ] : %s", block.NumberU64(), elem.Error.Error())
+			}
+		}
+	}
+
+	out := make([]*models.Balance, len(addresses))
+	for i, address := range addresses {
+		out[i] = &models.Balance{
+			Address:     address,
+			BlockNumber: block.NumberU64(),
+			BlockHash:   block.Hash().Hex(),
+			Balance:     balances[i].ToInt().String(),
+			Nonce:       uint64(nonces[i]),
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package block
+
+import (
+	"go-evm-indexer/models"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTouchedAddresses(t *testing.T) {
+	var (
+		miner    = common.HexToAddress("0x1").Hex()
+		sender   = common.HexToAddress("0x2").Hex()
+		receiver = common.HexToAddress("0x3").Hex()
+		created  = common.HexToAddress("0x4").Hex()
+		callee   = common.HexToAddress("0x5").Hex()
+		reverted = common.HexToAddress("0x6").Hex()
+		zero     = common.Address{}.Hex()
+	)
+
+	block := types.NewBlockWithHeader(&types.Header{Coinbase: common.HexToAddress(miner)})
+
+	tests := []struct {
+		name       string
+		bundledTxs []*models.BundledTransaction
+		want       []string
+	}{
+		{name: "miner of empty block", want: []string{miner}},
+		{
+			name: "transaction",
+			bundledTxs: []*models.BundledTransaction{
+				{Transaction: &models.Transaction{From: sender, To: receiver, Contract: zero}},
+			},
+			want: []string{miner, sender, receiver},
+		},
+		{
+			name: "contract creation",
+			bundledTxs: []*models.BundledTransaction{
+				{Transaction: &models.Transaction{From: sender, To: "", Contract: created}},
+			},
+			want: []string{miner, sender, created},
+		},
+		{
+			name: "internal calls that were not reverted",
+			bundledTxs: []*models.BundledTransaction{
+				{
+					Transaction: &models.Transaction{From: sender, To: receiver, Contract: zero},
+					InternalTransactions: []*models.InternalTransaction{
+						{From: receiver, To: callee},
+						{From: callee, To: reverted, Reverted: true},
+					},
+				},
+			},
+			want: []string{miner, sender, receiver, callee},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort.Strings(tt.want)
+
+			if got := touchedAddresses(block, tt.bundledTxs); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
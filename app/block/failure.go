@@ -0,0 +1,95 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+)
+
+// stageError error of block processing with stage that it has been failed at,
+// stages are the same as stages of failure metrics
+type stageError struct {
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+func withStage(stage string, err error) error {
+	return &stageError{stage: stage, err: err}
+}
+
+// stageOf function that returns stage of error, error without stage is from DB
+func stageOf(err error) string {
+	var stageErr *stageError
+	if errors.As(err, &stageErr) {
+		return stageErr.stage
+	}
+
+	return metrics.StageDB
+}
+
+// handleFailedBlock function that put failed block number back to queue to retry with backoff,
+// block will be stored to failed blocks when number of attempts reached maximum retries.
+//
+// Block that has been failed by chain reorganization is put back to queue without counting attempt
+func (b *Block) handleFailedBlock(number uint64, err error) {
+	if isReorg(err) {
+		log.Printf("⚠️ %s, [ block : %d ] is put back to queue\n", err.Error(), number)
+		b.queue.Requeue(number)
+		return
+	}
+
+	log.Printf("❌ %s\n", err.Error())
+
+	attempts, retry := b.queue.ConfirmedFailed(number)
+	if retry {
+		return
+	}
+
+	log.Printf("❌ [ block : %d ] failed after [ %d ] attempts, moved to failed blocks\n", number, attempts)
+
+	// Context of job may be already timed out
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := b.failedBlocksRepo.SaveFailedBlock(ctx, &models.FailedBlock{
+		Number:   number,
+		Attempts: attempts,
+		Stage:    stageOf(err),
+		Error:    err.Error(),
+		FailedAt: time.Now().Unix(),
+	}); err != nil {
+		log.Printf("❌ failed to save failed block [ block : %d ] : %s\n", number, err.Error())
+	}
+}
+
+// Requeue function that removes block from failed blocks and put it to queue to process again,
+// it returns false if block is not in failed blocks
+func (b *Block) Requeue(ctx context.Context, number uint64) (bool, error) {
+	failed, err := b.failedBlocksRepo.FindFailedBlockByNumber(ctx, number)
+	if err != nil {
+		return false, err
+	}
+
+	if failed == nil {
+		return false, nil
+	}
+
+	if err := b.failedBlocksRepo.DeleteFailedBlock(ctx, number); err != nil {
+		return false, err
+	}
+
+	b.queue.Put(number)
+
+	return true, nil
+}
@@ -2,49 +2,234 @@ package block
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/config"
 	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
 	"log"
 	"math/big"
+	"sync/atomic"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// methodNotFoundErrorCode json-rpc error code when node doesn't support method
+const methodNotFoundErrorCode = -32601
+
 // fetchBlockByNumber Fetching block information by block number.
-// This is the main process for fetching block, transactions, and events inside block number
-func (b *Block) fetchBlockByNumber(ctx context.Context, number uint64) bool {
+// This is the main process for fetching block, transactions, and events inside block number,
+// returned error has stage that block has been failed at
+func (b *Block) fetchBlockByNumber(ctx context.Context, number uint64) error {
+	return b.fetchBlock(ctx, number, false)
+}
+
+// fetchBlock function that fetches block and stores it, stored block of the same number is replaced
+// in the same transaction when replace is true.
+//
+// Every failure of block processing is counted here by its stage
+func (b *Block) fetchBlock(ctx context.Context, number uint64, replace bool) error {
+	err := b.indexBlock(ctx, number, replace)
+	if err != nil {
+		metrics.Failures.WithLabelValues(stageOf(err)).Inc()
+	}
+
+	return err
+}
+
+// indexBlock function that fetches block and stores it, returned error has stage that block has been failed at
+func (b *Block) indexBlock(ctx context.Context, number uint64, replace bool) error {
 	num := big.NewInt(0)
 	num.SetUint64(number)
 
 	log.Printf("✅ [ block : %d ] job is running\n", number)
 
+	start := time.Now()
 	block, err := b.blockChainNodeConn.RPC.BlockByNumber(ctx, num)
+	metrics.ObserveRPC("eth_getBlockByNumber", start)
 	if err != nil {
-		log.Printf("❌ failed to fetch block by number [ block : %d ]\n", num)
-		return false
+		return withStage(metrics.StageFetchBlock, fmt.Errorf("failed to fetch block by number [ block : %d ] : %s", number, err.Error()))
 	}
 
 	log.Printf("✅ [ block : %d ] [ tx : %d ] found \n", number, block.Transactions().Len())
 
-	if err := b.processBlockInfo(ctx, block); err != nil {
-		log.Printf("❌ failed to process block info [ block : %d ] : %s\n", num, err.Error())
-		return false
+	if err := b.processBlockInfo(ctx, block, replace); err != nil {
+		// Block has been indexed by other job meanwhile
+		if errors.Is(err, repository.ErrBlockExists) {
+			log.Printf("✅ [ block : %d ] already indexed\n", number)
+			return nil
+		}
+
+		// Block is not retried here, it is put back to queue with orphaned blocks by handling failed block
+		if isReorg(err) {
+			if err := b.handleReorg(ctx, number); err != nil {
+				return withStage(metrics.StageReorg, err)
+			}
+
+			return withStage(metrics.StageReorg, err)
+		}
+
+		return withStage(stageOf(err), fmt.Errorf("failed to process block info [ block : %d ] : %s", number, err.Error()))
 	}
 
-	return true
+	return nil
 }
 
-// fetchTransactionByHash function that fetching transaction and event log of transaction
-func (b *Block) fetchTransactionByHash(ctx context.Context, block *types.Block, tx *types.Transaction) (*models.BundledTransaction, error) {
-	receipt, err := b.blockChainNodeConn.RPC.TransactionReceipt(ctx, tx.Hash())
+// fetchTotalDifficulty function that fetches total difficulty of block, it is not a field of block header
+// so go-ethereum client doesn't return it. It returns nil if node doesn't return total difficulty
+func (b *Block) fetchTotalDifficulty(ctx context.Context, block *types.Block) (*big.Int, error) {
+	var head *struct {
+		TotalDifficulty *hexutil.Big `json:"totalDifficulty"`
+	}
+
+	start := time.Now()
+	err := b.blockChainNodeConn.RPC.CallContext(ctx, &head, "eth_getBlockByHash", block.Hash(), false)
+	metrics.ObserveRPC("eth_getBlockByHash", start)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction receipt [ block : %d ] : %s", block.NumberU64(), err.Error())
+		return nil, fmt.Errorf("failed to fetch total difficulty [ block : %d ] : %s", block.NumberU64(), err.Error())
 	}
 
-	sender, err := b.blockChainNodeConn.RPC.TransactionSender(context.Background(), tx, block.Hash(), receipt.TransactionIndex)
+	// Block has been orphaned since it was fetched
+	if head == nil {
+		return nil, fmt.Errorf("failed to fetch total difficulty [ block : %d ] : block not found", block.NumberU64())
+	}
+
+	return (*big.Int)(head.TotalDifficulty), nil
+}
+
+// fetchTransactions function that fetching receipts of all transactions in block and then
+// bundle transactions with their events
+//
+// Sender of transaction is recovered from signature locally, it doesn't need to call node
+func (b *Block) fetchTransactions(ctx context.Context, block *types.Block) ([]*models.BundledTransaction, error) {
+	if block.Transactions().Len() == 0 {
+		return nil, nil
+	}
+
+	receipts, err := b.fetchReceipts(ctx, block)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction sender [ block : %d ] : %s", block.NumberU64(), err.Error())
+		return nil, withStage(metrics.StageFetchReceipt, fmt.Errorf("failed to fetch transaction receipts [ block : %d ] : %s", block.NumberU64(), err.Error()))
+	}
+
+	bundledTxs := make([]*models.BundledTransaction, block.Transactions().Len())
+	for i, tx := range block.Transactions() {
+		sender, err := types.Sender(b.signer, tx)
+		if err != nil {
+			return nil, withStage(metrics.StageSender, fmt.Errorf("failed to recover transaction sender [ block : %d ] [ tx : %s ] : %s", block.NumberU64(), tx.Hash().Hex(), err.Error()))
+		}
+
+		bundledTxs[i] = transformTransaction(tx, sender, receipts[i], block.BaseFee(), b.registry)
+	}
+
+	if config.Get().TraceInternalTransactions {
+		internalTxs, err := b.fetchInternalTransactions(ctx, block)
+		if err != nil {
+			return nil, withStage(metrics.StageTrace, err)
+		}
+
+		for i := range bundledTxs {
+			bundledTxs[i].InternalTransactions = internalTxs[i]
+		}
+	}
+
+	return bundledTxs, nil
+}
+
+// fetchReceipts function that fetching receipts of all transactions in block, receipts are sorted by transaction index.
+//
+// It uses `eth_getBlockReceipts` if node supports it, otherwise `eth_getTransactionReceipt` will be called by batch requests
+func (b *Block) fetchReceipts(ctx context.Context, block *types.Block) ([]*types.Receipt, error) {
+	if atomic.LoadInt32(&b.blockReceiptsUnsupported) == 0 {
+		var receipts []*types.Receipt
+		start := time.Now()
+		err := b.blockChainNodeConn.RPC.CallContext(ctx, &receipts, "eth_getBlockReceipts", hexutil.EncodeBig(block.Number()))
+		metrics.ObserveRPC("eth_getBlockReceipts", start)
+		if err == nil {
+			if err := validateReceipts(block, receipts); err != nil {
+				return nil, err
+			}
+
+			return receipts, nil
+		}
+
+		var rpcErr rpc.Error
+		if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != methodNotFoundErrorCode {
+			return nil, err
+		}
+
+		log.Println("⚠️ eth_getBlockReceipts is not supported by node, switch to batch of eth_getTransactionReceipt")
+		atomic.StoreInt32(&b.blockReceiptsUnsupported, 1)
+	}
+
+	var (
+		txs       = block.Transactions()
+		receipts  = make([]*types.Receipt, txs.Len())
+		batchSize = config.Get().RPCBatchSize
+	)
+
+	if batchSize <= 0 {
+		batchSize = txs.Len()
+	}
+
+	for from := 0; from < txs.Len(); from += batchSize {
+		to := from + batchSize
+		if to > txs.Len() {
+			to = txs.Len()
+		}
+
+		batch := make([]rpc.BatchElem, 0, to-from)
+		for i := from; i < to; i++ {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{txs[i].Hash()},
+				Result: &receipts[i],
+			})
+		}
+
+		start := time.Now()
+		err := b.blockChainNodeConn.RPC.BatchCallContext(ctx, batch)
+		metrics.ObserveRPC("batch_eth_getTransactionReceipt", start)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+		}
+	}
+
+	if err := validateReceipts(block, receipts); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+// validateReceipts function that check receipts are matched with transactions of block
+func validateReceipts(block *types.Block, receipts []*types.Receipt) error {
+	if len(receipts) != block.Transactions().Len() {
+		return fmt.Errorf("number of receipts [%d] is not matched with number of transactions [%d]", len(receipts), block.Transactions().Len())
+	}
+
+	for i, tx := range block.Transactions() {
+		if receipts[i] == nil {
+			return fmt.Errorf("receipt not found [ tx : %s ]", tx.Hash().Hex())
+		}
+
+		if receipts[i].TxHash != tx.Hash() {
+			return fmt.Errorf("receipt is not matched with transaction [ tx : %s ]", tx.Hash().Hex())
+		}
+
+		if receipts[i].BlockNumber == nil || receipts[i].BlockNumber.Cmp(block.Number()) != 0 {
+			return fmt.Errorf("receipt is not matched with block [ tx : %s ]", tx.Hash().Hex())
+		}
 	}
 
-	return transformTransaction(tx, sender, receipt), nil
+	return nil
 }
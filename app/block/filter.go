@@ -0,0 +1,118 @@
+package block
+
+import (
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"log"
+
+	c "go-evm-indexer/app/common"
+)
+
+// filter restricts which transactions and events are stored, it keeps everything when no filters are configured
+type filter struct {
+	contracts  map[string]bool
+	signatures map[string]bool
+	from       map[string]bool
+	to         map[string]bool
+}
+
+// newFilter function that creates filter from comma separated addresses and event signatures of config
+func newFilter(cfg config.Config) *filter {
+	f := &filter{
+		contracts:  c.AddressSet(cfg.WatchContracts),
+		signatures: c.EventSignatureSet(cfg.WatchEventSignatures),
+		from:       c.AddressSet(cfg.WatchFrom),
+		to:         c.AddressSet(cfg.WatchTo),
+	}
+
+	if f.enabled() {
+		log.Printf("filter is enabled [ contracts : %d ] [ event signatures : %d ] [ from : %d ] [ to : %d ]\n",
+			len(f.contracts), len(f.signatures), len(f.from), len(f.to))
+	}
+
+	return f
+}
+
+func (f *filter) enabled() bool {
+	return len(f.contracts) > 0 || len(f.signatures) > 0 || len(f.from) > 0 || len(f.to) > 0
+}
+
+// filterEvents returns true if events are filtered by contract address or event signature,
+// otherwise all events of matched transactions are stored
+func (f *filter) filterEvents() bool {
+	return len(f.contracts) > 0 || len(f.signatures) > 0
+}
+
+// matchEvent function that checks event is emitted by watched contract and has watched signature
+func (f *filter) matchEvent(event *models.Event) bool {
+	if !f.filterEvents() {
+		return false
+	}
+
+	if len(f.contracts) > 0 && !f.contracts[event.Origin] {
+		return false
+	}
+
+	if len(f.signatures) > 0 && (len(event.Topics) == 0 || !f.signatures[event.Topics[0]]) {
+		return false
+	}
+
+	return true
+}
+
+// matchTransaction function that checks transaction is sent from or to watched address, or calls or creates watched contract
+func (f *filter) matchTransaction(tx *models.Transaction) bool {
+	return f.from[tx.From] || f.to[tx.To] || f.contracts[tx.To] || f.contracts[tx.Contract]
+}
+
+// apply function that returns only transactions that are matched or have matched events,
+// events and token transfers of returned transactions are filtered as well when events are filtered
+func (f *filter) apply(bundledTxs []*models.BundledTransaction) []*models.BundledTransaction {
+	if !f.enabled() {
+		return bundledTxs
+	}
+
+	var out []*models.BundledTransaction
+	for _, bundledTx := range bundledTxs {
+		if !f.filterEvents() {
+			if f.matchTransaction(bundledTx.Transaction) {
+				out = append(out, bundledTx)
+			}
+
+			continue
+		}
+
+		var (
+			events     []*models.Event
+			logIndexes = make(map[uint]bool)
+			transfers  []*models.TokenTransfer
+		)
+
+		for _, event := range bundledTx.Events {
+			if f.matchEvent(event) {
+				events = append(events, event)
+				logIndexes[event.Index] = true
+			}
+		}
+
+		if len(events) == 0 && !f.matchTransaction(bundledTx.Transaction) {
+			continue
+		}
+
+		// Token transfers are extracted from events, those are kept only if their events are kept
+		for _, transfer := range bundledTx.TokenTransfers {
+			if logIndexes[transfer.LogIndex] {
+				transfers = append(transfers, transfer)
+			}
+		}
+
+		out = append(out, &models.BundledTransaction{
+			Transaction:          bundledTx.Transaction,
+			Events:               events,
+			TokenTransfers:       transfers,
+			InternalTransactions: bundledTx.InternalTransactions,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,110 @@
+package block
+
+import (
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestFilterApply(t *testing.T) {
+	var (
+		watched  = common.HexToAddress("0x1").Hex()
+		other    = common.HexToAddress("0x2").Hex()
+		sender   = common.HexToAddress("0x3").Hex()
+		transfer = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")).Hex()
+		approval = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)")).Hex()
+	)
+
+	// Transaction `a` calls watched contract that emits transfer and approval, `b` is sent by sender to other
+	// address that emits transfer, `c` creates watched contract
+	bundledTxs := []*models.BundledTransaction{
+		{
+			Transaction: &models.Transaction{Hash: "a", From: other, To: watched},
+			Events: []*models.Event{
+				{Index: 0, Origin: watched, Topics: []string{transfer}},
+				{Index: 1, Origin: watched, Topics: []string{approval}},
+			},
+			TokenTransfers: []*models.TokenTransfer{{LogIndex: 0}},
+		},
+		{
+			Transaction:    &models.Transaction{Hash: "b", From: sender, To: other},
+			Events:         []*models.Event{{Index: 2, Origin: other, Topics: []string{transfer}}},
+			TokenTransfers: []*models.TokenTransfer{{LogIndex: 2}},
+		},
+		{
+			Transaction: &models.Transaction{Hash: "c", From: other, Contract: watched},
+		},
+	}
+
+	// result is hashes of transactions with indexes of their events and log indexes of their token transfers
+	type result struct {
+		Hash      string
+		Events    []uint
+		Transfers []uint
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []result
+	}{
+		{
+			name: "no filters",
+			want: []result{{Hash: "a", Events: []uint{0, 1}, Transfers: []uint{0}}, {Hash: "b", Events: []uint{2}, Transfers: []uint{2}}, {Hash: "c"}},
+		},
+		{
+			name: "watched contract",
+			cfg:  config.Config{WatchContracts: watched},
+			want: []result{{Hash: "a", Events: []uint{0, 1}, Transfers: []uint{0}}, {Hash: "c"}},
+		},
+		{
+			name: "watched event signature keeps only matched events",
+			cfg:  config.Config{WatchEventSignatures: "Transfer(address,address,uint256)"},
+			want: []result{{Hash: "a", Events: []uint{0}, Transfers: []uint{0}}, {Hash: "b", Events: []uint{2}, Transfers: []uint{2}}},
+		},
+		{
+			name: "many watched event signatures",
+			cfg:  config.Config{WatchEventSignatures: "Transfer(address,address,uint256), " + approval},
+			want: []result{{Hash: "a", Events: []uint{0, 1}, Transfers: []uint{0}}, {Hash: "b", Events: []uint{2}, Transfers: []uint{2}}},
+		},
+		{
+			name: "watched contract and event signature",
+			cfg:  config.Config{WatchContracts: watched, WatchEventSignatures: approval},
+			want: []result{{Hash: "a", Events: []uint{1}}, {Hash: "c"}},
+		},
+		{
+			name: "watched sender keeps all events of its transactions",
+			cfg:  config.Config{WatchFrom: sender},
+			want: []result{{Hash: "b", Events: []uint{2}, Transfers: []uint{2}}},
+		},
+		{
+			name: "watched receiver",
+			cfg:  config.Config{WatchTo: other},
+			want: []result{{Hash: "b", Events: []uint{2}, Transfers: []uint{2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []result
+			for _, bundledTx := range newFilter(tt.cfg).apply(bundledTxs) {
+				r := result{Hash: bundledTx.Transaction.Hash}
+				for _, event := range bundledTx.Events {
+					r.Events = append(r.Events, event.Index)
+				}
+				for _, transfer := range bundledTx.TokenTransfers {
+					r.Transfers = append(r.Transfers, transfer.LogIndex)
+				}
+				got = append(got, r)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
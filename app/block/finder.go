@@ -1,10 +1,26 @@
 package block
 
 import (
+	"context"
+	"fmt"
+	"go-evm-indexer/app/metrics"
 	"go-evm-indexer/models"
 	"sort"
+	"time"
 )
 
+// findMissingBlocks function that returns block numbers in range that are not indexed
+func (b *Block) findMissingBlocks(ctx context.Context, from, to uint64) ([]uint64, error) {
+	start := time.Now()
+	blocks, err := b.blocksRepo.FindBlockByRange(ctx, from, to)
+	metrics.ObserveDB("find_block_by_range", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find block by range [ from : %d ] [ to : %d ] from db : %s", from, to, err.Error())
+	}
+
+	return findMissingBlocksInRange(blocks, from, to), nil
+}
+
 // findMissingBlocksInRange return missing blocks from range input
 //
 // It finds blocks that are not numerically aligned or jumping number
@@ -3,7 +3,7 @@ package block
 import (
 	"context"
 	"fmt"
-	"go-evm-indexer/app/queue"
+	"go-evm-indexer/app/metrics"
 	"go-evm-indexer/config"
 	"go-evm-indexer/entity"
 	"log"
@@ -16,119 +16,109 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gammazero/workerpool"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func (b *Block) prepareSubscriber(ctx context.Context) {
+func (b *Block) prepareSubscriber(ctx context.Context) error {
 	var (
 		latestBlockNo = uint64(0)
 	)
 
 	block, err := b.blocksRepo.FindLastestBlock(ctx)
 	if err != nil {
-		log.Fatalf("❌ failed to find latest block number from db : %s\n", err.Error())
+		return fmt.Errorf("failed to find latest block number from db : %s", err.Error())
 	}
 
 	if block != nil {
 		latestBlockNo = block.Number
 	}
 
-	b.queue = queue.New()
 	b.status = &entity.StateManager{
 		State: &entity.State{},
 		Mutex: &sync.RWMutex{},
 	}
 
 	b.status.SetLatestBlockNumberAtStartUp(latestBlockNo)
+	metrics.SetLatestIndexedBlock(latestBlockNo)
+
+	return nil
 }
 
 // deleteIncompleteBlocks function that delete incomplete blocks and remove all transactions and remove all events in that block
-func (b *Block) deleteIncompleteBlocks(ctx context.Context) {
-	err := b.rollback.ExecTransaction(ctx, func(sc mongo.SessionContext) error {
+func (b *Block) deleteIncompleteBlocks(ctx context.Context) error {
+	return b.rollback.ExecTransaction(ctx, func(sc context.Context) error {
 		blocks, err := b.blocksRepo.FindIncompleteBlock(sc)
 		if err != nil {
-			return fmt.Errorf("failed to find block incompleted from db : %s", err.Error())
+			return fmt.Errorf("failed to find block incompleted from db : %w", err)
 		}
 
 		if len(blocks) == 0 {
 			return nil
 		}
 
-		for _, block := range blocks {
-			if err := b.transactionsRepo.DeleteAllTransactionsByBlockHash(sc, common.HexToHash(block.Hash)); err != nil {
-				return fmt.Errorf("failed to delete all transactions from db : %s", err.Error())
-			}
-			if err := b.eventsRepo.DeleteAllEventsByBlockHash(sc, common.HexToHash(block.Hash)); err != nil {
-				return fmt.Errorf("failed to delete all events from db db : %s", err.Error())
-			}
+		if err := b.deleteBlocksData(sc, blocks); err != nil {
+			return err
 		}
 
 		if err := b.blocksRepo.DeleteAllIncompleteBlocks(sc); err != nil {
-			return fmt.Errorf("failed to delete all block incompleted from db : %s", err.Error())
+			return fmt.Errorf("failed to delete all block incompleted from db : %w", err)
 		}
 
 		return nil
 	})
-
-	if err != nil {
-		log.Fatalf("❌ %s\n", err.Error())
-	}
 }
 
 // subcribeToNewBlocksByRPC custom subcribe mode if rpc does not support SubscribeNewHead it should use this function,
-// this function will get new block header to channel input
-func (b *Block) subcribeToNewBlocksByRPC(headerChan chan *types.Header) {
+// this function will get new block header to channel input until context is canceled
+func (b *Block) subcribeToNewBlocksByRPC(ctx context.Context, headerChan chan *types.Header) {
 	log.Println("starting custom subcribe to new blocks by rpc...")
 
+	b.wg.Add(1)
 	go func(_headerChan chan *types.Header) {
+		defer b.wg.Done()
+
 		var blockNoBefore uint64
 
 		for {
-			var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-
-			block, err := b.blockChainNodeConn.RPC.BlockByNumber(ctx, nil)
-			if err != nil {
-				log.Printf("❌ failed to get latest block number: %s\n", err.Error())
-				continue
-			}
-			var latestBlockNo = block.NumberU64()
-
-			if latestBlockNo <= blockNoBefore {
-				continue
-			}
-
-			num := big.NewInt(0)
-			num.SetUint64(latestBlockNo)
-
-			header, err := b.blockChainNodeConn.RPC.HeaderByNumber(ctx, num)
+			header, err := b.fetchLatestHeader(ctx)
 			if err != nil {
-				log.Printf("❌ failed to get header by block number [ block : %d ] : %s\n", num, err.Error())
-				continue
+				log.Printf("❌ %s\n", err.Error())
+			} else if header.Number.Uint64() > blockNoBefore {
+				select {
+				case <-ctx.Done():
+					return
+				case _headerChan <- header:
+					blockNoBefore = header.Number.Uint64()
+				}
 			}
 
 			select {
 			case <-ctx.Done():
-				log.Fatalln("⏱ timeout: custom subcribe to new blocks by rpc")
-			default:
-				_headerChan <- header
-
-				blockNoBefore = latestBlockNo
-
-				cancel()
-				<-time.After(time.Duration(1) * time.Second)
+				return
+			case <-time.After(time.Duration(1) * time.Second):
 			}
 		}
 	}(headerChan)
 }
 
-// healthcheckSubscribe check connection of subscription
-func healthcheckSubscribe(subs ethereum.Subscription) {
-	go func(_subs ethereum.Subscription) {
-		for {
-			err := <-_subs.Err()
-			log.Fatalf("❌ listener stopped : %s\n", err.Error())
-		}
-	}(subs)
+// fetchLatestHeader function that fetch header of latest block with timeout
+func (b *Block) fetchLatestHeader(ctx context.Context) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	block, err := b.blockChainNodeConn.RPC.BlockByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block number : %s", err.Error())
+	}
+
+	num := big.NewInt(0)
+	num.SetUint64(block.NumberU64())
+
+	header, err := b.blockChainNodeConn.RPC.HeaderByNumber(ctx, num)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header by block number [ block : %d ] : %s", num, err.Error())
+	}
+
+	return header, nil
 }
 
 type ListenerOptions struct {
@@ -143,12 +133,26 @@ func WithListenerOptionsRPCSubscribe(options *ListenerOptions) {
 // ListenToNewBlocks function that listens for events when a new block header is created
 // First step when run this function system will sync data after that,
 // the system will sync the latest data to the DB only when a new block is created.
-func (b *Block) ListenToNewBlocks(optionFuncs ...func(*ListenerOptions)) {
+//
+// When subscription is dropped, it will resubscribe with backoff and receive new blocks by polling rpc meanwhile,
+// block numbers that were missed during the gap will be put to queue when the next header is received.
+//
+// It runs until context is canceled, then it will stop receiving new blocks and wait for
+// all running jobs to complete before return
+func (b *Block) ListenToNewBlocks(ctx context.Context, optionFuncs ...func(*ListenerOptions)) error {
 	var (
-		ctx        = context.Background()
 		headerChan = make(chan *types.Header)
+		// current subscription and its error, those are nil when using custom subscribe by rpc
+		subs    ethereum.Subscription
+		subsErr <-chan error
+		// new subscription from resubscribing
+		subsChan = make(chan ethereum.Subscription)
+		// stopPolling stops custom subscribe by rpc that is used while resubscribing
+		stopPolling = func() {}
 		// Flag to check for whether this is a first-time block header being received
 		isFirst = true
+		// hash of the latest received header, to skip the same header from subscription and polling
+		latestHash common.Hash
 	)
 
 	options := &ListenerOptions{}
@@ -156,52 +160,114 @@ func (b *Block) ListenToNewBlocks(optionFuncs ...func(*ListenerOptions)) {
 		optionFunc(options)
 	}
 
-	b.deleteIncompleteBlocks(ctx)
-	b.prepareSubscriber(ctx)
-	b.queue.Start()
+	b.webhooks = true
 
-	if options.IsRPCSubscribe {
-		// Try to connect rpc subcribe new head if cannot connect it will switch to use custom subcribe
-		subs, err := b.blockChainNodeConn.RPC.SubscribeNewHead(ctx, headerChan)
-		if err != nil {
-			log.Printf("❌ failed to rpc subscribe to block headers : %s\n", err.Error())
-			log.Printf("rpc subscribe did not open, try to subscribe by custom subscribe by rpc")
-			b.subcribeToNewBlocksByRPC(headerChan)
-		} else {
-			// If RPC allow to subscribe it will continue to subcribe like web socket mode
-			defer subs.Unsubscribe()
-			// healthcheck subscribe new head
-			healthcheckSubscribe(subs)
-		}
-	} else {
-		subs, err := b.blockChainNodeConn.Websocket.SubscribeNewHead(ctx, headerChan)
-		if err != nil {
-			log.Fatalf("❌ failed to subscribe to block headers : %s\n", err.Error())
+	if err := b.deleteIncompleteBlocks(ctx); err != nil {
+		return err
+	}
+	if err := b.prepareSyncState(ctx); err != nil {
+		return err
+	}
+	if err := b.prepareSubscriber(ctx); err != nil {
+		return err
+	}
+	b.queue.Start(ctx)
+
+	// Waiting for all background processes to stop
+	defer b.wg.Wait()
+
+	defer func() {
+		stopPolling()
+		if subs != nil {
+			subs.Unsubscribe()
 		}
-		defer subs.Unsubscribe()
+	}()
+
+	// pollWhileResubscribing starts custom subscribe by rpc until new subscription is made
+	pollWhileResubscribing := func() {
+		var pollCtx context.Context
+		pollCtx, stopPolling = context.WithCancel(ctx)
+		b.subcribeToNewBlocksByRPC(pollCtx, headerChan)
+		b.resubscribe(ctx, options, headerChan, subsChan)
+	}
 
-		// healthcheck subscribe new head
-		healthcheckSubscribe(subs)
+	subs, err := b.subscribeNewHead(ctx, options, headerChan)
+	switch {
+	case err != nil && options.IsRPCSubscribe:
+		// Try to connect rpc subcribe new head if cannot connect it will switch to use custom subcribe
+		log.Printf("❌ failed to rpc subscribe to block headers : %s\n", err.Error())
+		log.Printf("rpc subscribe did not open, try to subscribe by custom subscribe by rpc")
+		b.subcribeToNewBlocksByRPC(ctx, headerChan)
+	case err != nil:
+		log.Printf("❌ failed to subscribe to block headers : %s\n", err.Error())
+		pollWhileResubscribing()
+	default:
+		// If RPC allow to subscribe it will continue to subcribe like web socket mode
+		subsErr = subs.Err()
 	}
 
 	wp := workerpool.New(runtime.NumCPU() * int(config.Get().Concurrency))
-	defer wp.Stop()
+	defer wp.StopWait()
 
 	for {
-		header := <-headerChan
-		// Latest block number of subscriber must not lower than latest block number in DB
+		var header *types.Header
+
+		select {
+		case <-ctx.Done():
+			log.Println("stopping listener, waiting for running jobs...")
+			return nil
+		case err := <-subsErr:
+			if err == nil {
+				log.Println("⚠️ subscription closed, resubscribing...")
+			} else {
+				log.Printf("⚠️ subscription dropped, resubscribing... : %s\n", err.Error())
+			}
+
+			subs.Unsubscribe()
+			subs, subsErr = nil, nil
+			pollWhileResubscribing()
+			continue
+		case subs = <-subsChan:
+			log.Println("resubscribed to block headers")
+
+			stopPolling()
+			subsErr = subs.Err()
+			continue
+		case header = <-headerChan:
+		}
+
+		// The same header can be received from both subscription and polling while resubscribing
+		if header.Hash() == latestHash {
+			continue
+		}
+		latestHash = header.Hash()
+
+		// Latest block number of subscriber should not lower than latest block number in DB,
+		// It means node is behind or blocks in DB were orphaned, those will be checked by parent hash when processing
 		if isFirst && header.Number.Uint64() < b.status.GetLatestBlockNumberAtStartUp() {
-			log.Fatalf("❌ unexpected!!! bad block received : latest block number [%d] > latest block number in db [%d]\n", header.Number.Uint64(), b.status.GetLatestBlockNumberAtStartUp())
+			log.Printf("⚠️ latest block number [%d] < latest block number in db [%d]\n", header.Number.Uint64(), b.status.GetLatestBlockNumberAtStartUp())
 		}
 
-		// Latest block number of subscriber must not over than latest block number + 1,
-		// If it is exceeded, the system will sync again
-		if !isFirst && header.Number.Uint64() > b.status.GetLatestBlockNumber()+1 {
-			log.Fatalf("❌ unexpected!!! bad block received : latest block number [%d] > expected next block [%d]\n", header.Number.Uint64(), b.status.GetLatestBlockNumber()+1)
+		if !isFirst {
+			latestBlockNo := b.status.GetLatestBlockNumber()
+
+			// Same or lower block number is received again, it means chain head was reorganized.
+			// Orphaned blocks will be detected by parent hash when they are processed
+			if header.Number.Uint64() <= latestBlockNo {
+				log.Printf("⚠️ chain head reorganized [ block : %d ] [ latest block : %d ]\n", header.Number.Uint64(), latestBlockNo)
+				continue
+			}
+
+			// Latest block number of subscriber is over than latest block number + 1,
+			// skipped block numbers will be put to queue to process
+			for number := latestBlockNo + 1; number < header.Number.Uint64(); number++ {
+				b.queue.Put(number)
+			}
 		}
 
 		b.status.SetLatestBlockNumber(header.Number.Uint64())
 		b.queue.SetLatestBlockNumber(header.Number.Uint64())
+		metrics.SetChainHead(header.Number.Uint64())
 
 		if isFirst && header.Number.Uint64() > config.Get().NumberOfConfirmations {
 			var (
@@ -211,22 +277,46 @@ func (b *Block) ListenToNewBlocks(optionFuncs ...func(*ListenerOptions)) {
 				to = header.Number.Uint64() - config.Get().NumberOfConfirmations
 			)
 
-			go b.syncBlocksByRange(from, to)
+			b.wg.Add(1)
+			go func() {
+				defer b.wg.Done()
+				b.syncBlocksByRange(ctx, from, to)
+			}()
 			isFirst = false
 		}
 
 		fmt.Println(header.Number.Uint64())
 		b.queue.Put(header.Number.Uint64())
 
-		if nxtnum, ok := b.queue.ConfirmNext(); ok {
-			wp.Submit(func() {
-				var ctx, cancel = context.WithTimeout(context.Background(), time.Duration(config.Get().MaxJobTimeout)*time.Minute)
-				defer cancel()
+		b.confirmBlocks(ctx, wp)
+	}
+}
 
-				if !b.fetchBlockByNumber(ctx, nxtnum) {
-					b.queue.ConfirmedFailed(nxtnum)
-				}
-			})
+// confirmBlocks function that hands out all block numbers of queue that are ready to confirm to worker pool,
+// lowest number first. Failed block number is put back to queue to retry with backoff
+func (b *Block) confirmBlocks(rootCtx context.Context, wp *workerpool.WorkerPool) {
+	for {
+		nxtnum, ok := b.queue.ConfirmNext()
+		if !ok {
+			return
 		}
+
+		wp.Submit(func() {
+			// Job that has not been started yet will be skipped when shutting down
+			if rootCtx.Err() != nil {
+				return
+			}
+
+			// Running job is not canceled by shutting down, it will be completed or reached timeout
+			var ctx, cancel = context.WithTimeout(context.Background(), time.Duration(config.Get().MaxJobTimeout)*time.Minute)
+			defer cancel()
+
+			if err := b.fetchBlockByNumber(ctx, nxtnum); err != nil {
+				b.handleFailedBlock(nxtnum, err)
+				return
+			}
+
+			b.queue.ConfirmedDone(nxtnum)
+		})
 	}
 }
@@ -0,0 +1,108 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"log"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/gammazero/workerpool"
+)
+
+// migratePageSize is number of outdated blocks that are re-indexed in one round
+const migratePageSize = 100
+
+// MigrateBlocks function that re-index blocks that were stored by older schema version, so that all fields
+// of the current schema are filled. Each block is fetched again and replaced in place, subscribers of webhook
+// are not notified. Sink gets revert message of stored block followed by messages of re-indexed block,
+// so consumers replace records of the block instead of getting them twice.
+//
+// It runs until all outdated blocks are re-indexed or context is canceled,
+// blocks that can't be re-indexed are kept as they are and will be re-indexed by the next migration
+func (b *Block) MigrateBlocks(ctx context.Context) error {
+	var (
+		from     uint64
+		migrated uint64
+		failed   uint64
+	)
+
+	if err := b.prepareSyncState(ctx); err != nil {
+		return err
+	}
+
+	for ctx.Err() == nil {
+		blocks, err := b.blocksRepo.FindOutdatedBlocks(ctx, models.BlockSchemaVersion, from, migratePageSize)
+		if err != nil {
+			return fmt.Errorf("failed to find outdated blocks from db : %s", err.Error())
+		}
+
+		if len(blocks) == 0 {
+			break
+		}
+
+		wp := workerpool.New(runtime.NumCPU() * int(config.Get().Concurrency))
+		for _, block := range blocks {
+			number := block.Number
+			wp.Submit(func() {
+				if ctx.Err() != nil {
+					return
+				}
+
+				if err := b.reindexBlock(number); err != nil {
+					atomic.AddUint64(&failed, 1)
+					log.Printf("❌ failed to migrate block [ block : %d ] : %s\n", number, err.Error())
+					return
+				}
+
+				atomic.AddUint64(&migrated, 1)
+			})
+		}
+
+		// Waiting for the page to complete before finding next page
+		wp.StopWait()
+
+		from = blocks[len(blocks)-1].Number + 1
+		log.Printf("migrating blocks [ to block : %d ] [ migrated : %d ] [ failed : %d ]\n", from-1, atomic.LoadUint64(&migrated), atomic.LoadUint64(&failed))
+	}
+
+	if ctx.Err() != nil {
+		log.Printf("migration stopped [ migrated : %d ] [ failed : %d ]\n", migrated, failed)
+		return nil
+	}
+
+	log.Printf("✅ migration completed [ migrated : %d ] [ failed : %d ]\n", migrated, failed)
+
+	return nil
+}
+
+// reindexBlock function that fetches block again and replaces stored block in one transaction,
+// stored block is kept as it is if re-indexing is failed
+func (b *Block) reindexBlock(number uint64) error {
+	// Running job is not canceled by shutting down, it will be completed or reached timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Get().MaxJobTimeout)*time.Minute)
+	defer cancel()
+
+	return b.fetchBlock(ctx, number, true)
+}
+
+// deleteBlock function that delete stored block of number and all data related to it, it should be called in transaction
+func (b *Block) deleteBlock(ctx context.Context, number uint64) error {
+	blocks, err := b.blocksRepo.FindBlockByRange(ctx, number, number)
+	if err != nil {
+		return fmt.Errorf("failed to find block by range from db : %w", err)
+	}
+
+	if err := b.deleteBlocksData(ctx, blocks); err != nil {
+		return err
+	}
+
+	if err := b.blocksRepo.DeleteBlocksByRange(ctx, number, number); err != nil {
+		return fmt.Errorf("failed to delete blocks by range from db : %w", err)
+	}
+
+	return nil
+}
@@ -3,56 +3,214 @@ package block
 import (
 	"context"
 	"fmt"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/app/sink"
+	"go-evm-indexer/app/webhook"
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// processBlockInfo Fetching transactions and events of block and then insert to DB
-func (b *Block) processBlockInfo(ctx context.Context, block *types.Block) error {
+// processBlockInfo Fetching transactions and events of block and then insert to DB,
+// when replace is true stored block of the same number and all of its data are deleted in the same transaction
+func (b *Block) processBlockInfo(ctx context.Context, block *types.Block, replace bool) error {
+	start := time.Now()
 	blockInfo, err := b.blocksRepo.FindBlockByNumber(ctx, block.NumberU64())
+	metrics.ObserveDB("find_block_by_number", start)
 	if err != nil {
 		return fmt.Errorf("failed to get block by number from db : %s", err.Error())
 	}
 
-	if blockInfo != nil {
-		return fmt.Errorf("duplicate block number")
+	if blockInfo != nil && !replace {
+		return repository.ErrBlockExists
 	}
 
-	err = b.rollback.ExecTransaction(ctx, func(sc mongo.SessionContext) error {
+	// Fetching all receipts before opening transaction of DB to keep transaction short
+	bundledTxs, err := b.fetchTransactions(ctx, block)
+	if err != nil {
+		return err
+	}
+
+	totalDifficulty, err := b.fetchTotalDifficulty(ctx, block)
+	if err != nil {
+		return withStage(metrics.StageFetchBlock, err)
+	}
+
+	// Balances are tracked for all touched addresses, including those of transactions that are filtered out
+	var balances []*models.Balance
+	if config.Get().TrackBalances {
+		if balances, err = b.fetchBalances(ctx, block, bundledTxs); err != nil {
+			return withStage(metrics.StageBalance, err)
+		}
+	}
+
+	// Block is always stored even if all of its transactions are filtered out, to keep tracking missing blocks
+	bundledTxs = b.filter.apply(bundledTxs)
+
+	var (
+		txs         = make([]*models.Transaction, 0, len(bundledTxs))
+		events      []*models.Event
+		transfers   []*models.TokenTransfer
+		internalTxs []*models.InternalTransaction
+	)
+
+	for _, bundledTx := range bundledTxs {
+		txs = append(txs, bundledTx.Transaction)
+		events = append(events, bundledTx.Events...)
+		transfers = append(transfers, bundledTx.TokenTransfers...)
+		internalTxs = append(internalTxs, bundledTx.InternalTransactions...)
+	}
+
+	blockModel := transformBlock(block, totalDifficulty)
+
+	deliveries, err := b.webhookDeliveries(ctx, blockModel, txs, events)
+	if err != nil {
+		return err
+	}
+
+	// Stored block that is replaced is reverted first, so consumers of sink don't get its records twice
+	var replaced *models.Block
+	if replace {
+		replaced = blockInfo
+	}
+
+	messages, err := b.sinkMessages(replaced, blockModel, txs, events)
+	if err != nil {
+		return err
+	}
+
+	start = time.Now()
+	err = b.rollback.ExecTransaction(ctx, func(sc context.Context) error {
+		// Neighbours are checked in transaction, those may be committed by other jobs while fetching this block
+		if err := b.checkParentHash(sc, block.NumberU64(), block.ParentHash()); err != nil {
+			return err
+		}
+
+		if err := b.checkChildHash(sc, block.NumberU64(), block.Hash()); err != nil {
+			return err
+		}
+
+		if replace {
+			if err := b.deleteBlock(sc, block.NumberU64()); err != nil {
+				return err
+			}
+		}
+
 		// if any under scope is error system will rollback automatically
-		err := b.blocksRepo.AddBlock(sc, transformBlock(block))
+		err := b.blocksRepo.AddBlock(sc, blockModel)
 		if err != nil {
-			return fmt.Errorf("failed to add block to db : %s", err.Error())
-		}
-
-		if block.Transactions().Len() > 0 {
-			for _, tx := range block.Transactions() {
-				bundledTx, err := b.fetchTransactionByHash(sc, block, tx)
-				if err != nil {
-					return err
-				}
-
-				if err := b.transactionsRepo.AddTransaction(sc, bundledTx.Transaction); err != nil {
-					return fmt.Errorf("failed to add transaction to db : %s", err.Error())
-				}
-
-				for _, event := range bundledTx.Events {
-					err := b.eventsRepo.AddEvent(sc, event)
-					if err != nil {
-						return fmt.Errorf("failed to add event to db : %s", err.Error())
-					}
-				}
-			}
+			return fmt.Errorf("failed to add block to db : %w", err)
+		}
+
+		if err := b.transactionsRepo.AddTransactions(sc, txs); err != nil {
+			return fmt.Errorf("failed to add transactions to db : %w", err)
+		}
+
+		if err := b.eventsRepo.AddEvents(sc, events); err != nil {
+			return fmt.Errorf("failed to add events to db : %w", err)
+		}
+
+		if err := b.tokenTransfersRepo.AddTokenTransfers(sc, transfers); err != nil {
+			return fmt.Errorf("failed to add token transfers to db : %w", err)
+		}
+
+		if err := b.applyTokenTransfers(sc, transfers, false); err != nil {
+			return err
+		}
+
+		if err := b.internalTransactionsRepo.AddInternalTransactions(sc, internalTxs); err != nil {
+			return fmt.Errorf("failed to add internal transactions to db : %w", err)
+		}
+
+		if err := b.balancesRepo.AddBalances(sc, balances); err != nil {
+			return fmt.Errorf("failed to add balances to db : %w", err)
+		}
+
+		// Webhook deliveries are stored with block as outbox, those will be sent by dispatcher after committed
+		if err := b.webhookDeliveriesRepo.AddWebhookDeliveries(sc, deliveries); err != nil {
+			return fmt.Errorf("failed to add webhook deliveries to db : %w", err)
+		}
+
+		// Sink messages are stored with block as outbox, those will be published by relay after committed
+		if err := b.sinkMessagesRepo.AddSinkMessages(sc, messages); err != nil {
+			return fmt.Errorf("failed to add sink messages to db : %w", err)
 		}
 
 		_, err = b.blocksRepo.UpdateToDone(sc, block.NumberU64())
 		if err != nil {
-			return fmt.Errorf("failed to update to done : %s", err.Error())
+			return fmt.Errorf("failed to update to done : %w", err)
+		}
+
+		// Replaced block is already in sync state
+		if blockInfo != nil {
+			return nil
+		}
+
+		// Indexed block is added as a range of one block, it will be merged with adjacent ranges later.
+		// Transaction that is conflicted with merging ranges is retried by rollback
+		if err := b.syncStateRepo.AddSyncRange(sc, &models.SyncRange{From: block.NumberU64(), To: block.NumberU64()}); err != nil {
+			return fmt.Errorf("failed to add sync range to db : %w", err)
 		}
 
 		return nil
 	})
+	metrics.ObserveDB("insert_block", start)
+
+	if err != nil {
+		return err
+	}
+
+	metrics.BlocksIndexed.Inc()
+	metrics.TransactionsIndexed.Add(float64(len(txs)))
+	metrics.EventsIndexed.Add(float64(len(events)))
+	metrics.SetLatestIndexedBlock(block.NumberU64())
+
+	return nil
+}
+
+// webhookDeliveries function that returns deliveries of block to subscriptions, it returns nothing
+// when webhooks are disabled
+func (b *Block) webhookDeliveries(ctx context.Context, block *models.Block, txs []*models.Transaction, events []*models.Event) ([]*models.WebhookDelivery, error) {
+	if !b.webhooks {
+		return nil, nil
+	}
+
+	subscriptions, err := b.webhookSubscriptionsRepo.FindWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscriptions from db : %s", err.Error())
+	}
+
+	deliveries, err := webhook.Deliveries(subscriptions, block, txs, events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook deliveries : %s", err.Error())
+	}
+
+	return deliveries, nil
+}
+
+// sinkMessages function that returns outbox messages of block, revert message of replaced block is put before them
+// when it is given. It returns nothing when sink is disabled
+func (b *Block) sinkMessages(replaced *models.Block, block *models.Block, txs []*models.Transaction, events []*models.Event) ([]*models.SinkMessage, error) {
+	if !b.sink {
+		return nil, nil
+	}
+
+	var messages []sink.Message
+	if replaced != nil {
+		reverts, err := sink.RevertMessages([]models.Block{*replaced})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sink messages : %s", err.Error())
+		}
+		messages = append(messages, reverts...)
+	}
+
+	blockMessages, err := sink.BlockMessages(block, txs, events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sink messages : %s", err.Error())
+	}
 
-	return err
+	return sink.Outbox(append(messages, blockMessages...)), nil
 }
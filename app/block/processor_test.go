@@ -0,0 +1,47 @@
+package block
+
+import (
+	"go-evm-indexer/app/sink"
+	"go-evm-indexer/models"
+	"testing"
+)
+
+func TestSinkMessages(t *testing.T) {
+	var (
+		block    = &models.Block{Number: 5, Hash: "0xnew"}
+		replaced = &models.Block{Number: 5, Hash: "0xold"}
+		txs      = []*models.Transaction{{Hash: "0x1"}}
+	)
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		replaced *models.Block
+		want     []string
+	}{
+		{name: "disabled", enabled: false, want: nil},
+		{name: "indexed block", enabled: true, want: []string{sink.TypeBlock, sink.TypeTransaction}},
+		{name: "replaced block is reverted first", enabled: true, replaced: replaced, want: []string{sink.TypeRevert, sink.TypeBlock, sink.TypeTransaction}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Block{sink: tt.enabled}
+
+			messages, err := b.sinkMessages(tt.replaced, block, txs, nil)
+			if err != nil {
+				t.Fatalf("failed to create messages : %s", err.Error())
+			}
+
+			if len(messages) != len(tt.want) {
+				t.Fatalf("got %d messages, want %d", len(messages), len(tt.want))
+			}
+
+			for i, message := range messages {
+				if message.Type != tt.want[i] || message.Index != i || message.Key != "5" {
+					t.Fatalf("message %d : got [ type : %s ] [ index : %d ] [ key : %s ], want [ type : %s ] [ index : %d ] [ key : 5 ]", i, message.Type, message.Index, message.Key, tt.want[i], i)
+				}
+			}
+		})
+	}
+}
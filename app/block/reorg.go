@@ -0,0 +1,318 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-evm-indexer/app/sink"
+	"go-evm-indexer/app/webhook"
+	"go-evm-indexer/models"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// errReorgDetected is returned when parent hash of a fetched block doesn't match hash of the previous block
+// stored in DB, or hash of a fetched block doesn't match parent hash of the next block stored in DB
+type errReorgDetected struct {
+	number uint64
+}
+
+func (e *errReorgDetected) Error() string {
+	return fmt.Sprintf("chain reorganization detected [ block : %d ]", e.number)
+}
+
+// checkParentHash function that compare parent hash of block with hash of block N-1 in DB,
+// if block N-1 hasn't been indexed yet it can't be checked and will be passed
+func (b *Block) checkParentHash(ctx context.Context, number uint64, parentHash common.Hash) error {
+	if number == 0 {
+		return nil
+	}
+
+	parent, err := b.blocksRepo.FindBlockByNumber(ctx, number-1)
+	if err != nil {
+		return fmt.Errorf("failed to get block by number from db : %w", err)
+	}
+
+	if parent != nil && common.HexToHash(parent.Hash) != parentHash {
+		return &errReorgDetected{number: number}
+	}
+
+	return nil
+}
+
+// checkChildHash function that compare hash of block with parent hash of block N+1 in DB,
+// if block N+1 hasn't been indexed yet it can't be checked and will be passed
+func (b *Block) checkChildHash(ctx context.Context, number uint64, hash common.Hash) error {
+	child, err := b.blocksRepo.FindBlockByNumber(ctx, number+1)
+	if err != nil {
+		return fmt.Errorf("failed to get block by number from db : %w", err)
+	}
+
+	if child != nil && common.HexToHash(child.ParentHash) != hash {
+		return &errReorgDetected{number: number}
+	}
+
+	return nil
+}
+
+// findCommonAncestor function that walks back from block number until hash of block in DB
+// matched with hash of canonical block from blockchain node. Gap of blocks that haven't been indexed
+// is skipped, the nearest indexed block below the gap is compared with canonical block instead
+//
+// It returns number of common ancestor and false when there are not any indexed blocks that match canonical chain
+func (b *Block) findCommonAncestor(ctx context.Context, number uint64) (uint64, bool, error) {
+	for n := number; ; n-- {
+		stored, err := b.blocksRepo.FindBlockByNumber(ctx, n)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get block by number from db : %w", err)
+		}
+
+		if stored == nil {
+			blocks, err := b.blocksRepo.FindBlocksByCursor(ctx, n, 1)
+			if err != nil {
+				return 0, false, fmt.Errorf("failed to find blocks by cursor from db : %w", err)
+			}
+
+			if len(blocks) == 0 {
+				return 0, false, nil
+			}
+
+			stored, n = &blocks[0], blocks[0].Number
+		}
+
+		header, err := b.blockChainNodeConn.RPC.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get header by block number [ block : %d ] : %w", n, err)
+		}
+
+		if header.Hash() == common.HexToHash(stored.Hash) {
+			return n, true, nil
+		}
+
+		if n == 0 {
+			return 0, false, nil
+		}
+	}
+}
+
+// findOrphanedDescendant function that walks forward from block number while blocks in DB don't match
+// canonical blocks from blockchain node, block that is higher than head of canonical chain is orphaned as well
+//
+// It returns number of the last orphaned block and false when block in DB at block number is not orphaned
+func (b *Block) findOrphanedDescendant(ctx context.Context, number uint64) (uint64, bool, error) {
+	var (
+		last  uint64
+		found bool
+	)
+
+	for n := number; ; n++ {
+		stored, err := b.blocksRepo.FindBlockByNumber(ctx, n)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get block by number from db : %w", err)
+		}
+
+		if stored == nil {
+			return last, found, nil
+		}
+
+		header, err := b.blockChainNodeConn.RPC.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return 0, false, fmt.Errorf("failed to get header by block number [ block : %d ] : %w", n, err)
+		}
+
+		if header != nil && header.Hash() == common.HexToHash(stored.Hash) {
+			return last, found, nil
+		}
+
+		last, found = n, true
+	}
+}
+
+// handleReorg function that rollback orphaned blocks around block N and put them back to queue,
+// block N and orphaned blocks will be fetched again from canonical chain by workers of queue
+//
+// It walks back from block N-1 to find common ancestor and walks forward from block N+1 to find the last
+// orphaned block, then delete all orphaned blocks, transactions and events in one transaction
+func (b *Block) handleReorg(ctx context.Context, number uint64) error {
+	// first and last orphaned block number, block N itself hasn't been stored
+	from, to := number, number
+
+	if number > 0 {
+		ancestor, found, err := b.findCommonAncestor(ctx, number-1)
+		if err != nil {
+			return fmt.Errorf("failed to find common ancestor [ block : %d ] : %w", number, err)
+		}
+
+		from = 0
+		if found {
+			from = ancestor + 1
+		}
+	}
+
+	last, found, err := b.findOrphanedDescendant(ctx, number+1)
+	if err != nil {
+		return fmt.Errorf("failed to find orphaned descendant [ block : %d ] : %w", number, err)
+	}
+
+	if found {
+		to = last
+	}
+
+	log.Printf("⚠️ chain reorganization [ block : %d ] rollback from [ block : %d ] to [ block : %d ]\n", number, from, to)
+
+	blocks, err := b.rollbackBlocksByRange(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to rollback orphaned blocks [ from : %d ] [ to : %d ] : %w", from, to, err)
+	}
+
+	for _, block := range blocks {
+		b.queue.Requeue(block.Number)
+	}
+
+	return nil
+}
+
+// rollbackBlocksByRange function that delete orphaned blocks by range and remove all transactions and events in that blocks,
+// subscribers of webhook are notified when webhooks are enabled. It returns deleted blocks
+func (b *Block) rollbackBlocksByRange(ctx context.Context, from, to uint64) ([]models.Block, error) {
+	var blocks []models.Block
+
+	err := b.rollback.ExecTransaction(ctx, func(sc context.Context) error {
+		var err error
+		blocks, err = b.blocksRepo.FindBlockByRange(sc, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to find block by range from db : %w", err)
+		}
+
+		// Subscriptions are notified before data of blocks is deleted, it is needed to match subscriptions
+		if b.webhooks {
+			if err := b.notifyReorg(sc, blocks); err != nil {
+				return err
+			}
+		}
+
+		if err := b.deleteBlocksData(sc, blocks); err != nil {
+			return err
+		}
+
+		if err := b.blocksRepo.DeleteBlocksByRange(sc, from, to); err != nil {
+			return fmt.Errorf("failed to delete blocks by range from db : %w", err)
+		}
+
+		if err := b.removeFromSyncState(sc, from, to); err != nil {
+			return err
+		}
+
+		if b.sink {
+			messages, err := sink.RevertMessages(blocks)
+			if err != nil {
+				return fmt.Errorf("failed to create sink messages : %s", err.Error())
+			}
+
+			if err := b.sinkMessagesRepo.AddSinkMessages(sc, sink.Outbox(messages)); err != nil {
+				return fmt.Errorf("failed to add sink messages to db : %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// deleteBlocksData function that remove all data related to blocks except blocks itself
+func (b *Block) deleteBlocksData(ctx context.Context, blocks []models.Block) error {
+	for _, block := range blocks {
+		if err := b.transactionsRepo.DeleteAllTransactionsByBlockHash(ctx, common.HexToHash(block.Hash)); err != nil {
+			return fmt.Errorf("failed to delete all transactions from db : %w", err)
+		}
+		if err := b.eventsRepo.DeleteAllEventsByBlockHash(ctx, common.HexToHash(block.Hash)); err != nil {
+			return fmt.Errorf("failed to delete all events from db : %w", err)
+		}
+		if err := b.revertTokenBalances(ctx, common.HexToHash(block.Hash)); err != nil {
+			return err
+		}
+		if err := b.tokenTransfersRepo.DeleteAllTokenTransfersByBlockHash(ctx, common.HexToHash(block.Hash)); err != nil {
+			return fmt.Errorf("failed to delete all token transfers from db : %w", err)
+		}
+		if err := b.internalTransactionsRepo.DeleteAllInternalTransactionsByBlockHash(ctx, common.HexToHash(block.Hash)); err != nil {
+			return fmt.Errorf("failed to delete all internal transactions from db : %w", err)
+		}
+		if err := b.balancesRepo.DeleteAllBalancesByBlockHash(ctx, common.HexToHash(block.Hash)); err != nil {
+			return fmt.Errorf("failed to delete all balances from db : %w", err)
+		}
+	}
+
+	return nil
+}
+
+// notifyReorg function that adds webhook deliveries of orphaned blocks to subscriptions that have matched transactions
+// or events of those blocks, it should be called in transaction before data of blocks is deleted
+func (b *Block) notifyReorg(ctx context.Context, blocks []models.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	subscriptions, err := b.webhookSubscriptionsRepo.FindWebhookSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find webhook subscriptions from db : %w", err)
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for i := range blocks {
+		txs, err := b.transactionsRepo.FindTransactionsByBlockHash(ctx, common.HexToHash(blocks[i].Hash))
+		if err != nil {
+			return fmt.Errorf("failed to find transactions by block hash from db : %w", err)
+		}
+
+		events, err := b.eventsRepo.FindEventsByBlockHash(ctx, common.HexToHash(blocks[i].Hash))
+		if err != nil {
+			return fmt.Errorf("failed to find events by block hash from db : %w", err)
+		}
+
+		blockDeliveries, err := webhook.ReorgDeliveries(subscriptions, &blocks[i], transactionPointers(txs), eventPointers(events))
+		if err != nil {
+			return fmt.Errorf("failed to create webhook deliveries : %w", err)
+		}
+		deliveries = append(deliveries, blockDeliveries...)
+	}
+
+	if err := b.webhookDeliveriesRepo.AddWebhookDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("failed to add webhook deliveries to db : %w", err)
+	}
+
+	return nil
+}
+
+func transactionPointers(txs []models.Transaction) []*models.Transaction {
+	out := make([]*models.Transaction, len(txs))
+	for i := range txs {
+		out[i] = &txs[i]
+	}
+
+	return out
+}
+
+func eventPointers(events []models.Event) []*models.Event {
+	out := make([]*models.Event, len(events))
+	for i := range events {
+		out[i] = &events[i]
+	}
+
+	return out
+}
+
+func isReorg(err error) bool {
+	var reorgErr *errReorgDetected
+	return errors.As(err, &reorgErr)
+}
@@ -0,0 +1,197 @@
+package block
+
+import (
+	"context"
+	"go-evm-indexer/app/rpcpool"
+	"go-evm-indexer/entity"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"math/big"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// canonicalHead is head of canonical chain of test node
+const canonicalHead = 9
+
+// header function that returns header of block number on canonical chain or on fork,
+// headers of different chains have different hashes
+func header(number uint64, fork bool) *types.Header {
+	h := &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(1)}
+	if fork {
+		h.Extra = []byte("fork")
+	}
+
+	return h
+}
+
+// chainNode `eth` namespace of test node that serves canonical chain up to head
+type chainNode struct{}
+
+func (chainNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func (chainNode) BlockNumber() hexutil.Uint64 {
+	return canonicalHead
+}
+
+func (chainNode) GetBlockByNumber(number rpc.BlockNumber, _ bool) (*types.Header, error) {
+	if number < 0 || number > canonicalHead {
+		return nil, nil
+	}
+
+	return header(uint64(number), false), nil
+}
+
+func dialChainNode(t *testing.T) *rpcpool.Pool {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chainNode{}); err != nil {
+		t.Fatalf("failed to register test node : %s", err.Error())
+	}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	pool, err := rpcpool.Dial(context.Background(), []string{httpServer.URL})
+	if err != nil {
+		t.Fatalf("failed to dial test node : %s", err.Error())
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+type fakeBlocksRepo struct {
+	repository.IBlocksRepository
+	blocks map[uint64]models.Block
+}
+
+// newFakeBlocksRepo function that stores blocks of numbers, block is on fork when it is in forked
+func newFakeBlocksRepo(numbers []uint64, forked []uint64) *fakeBlocksRepo {
+	isForked := make(map[uint64]bool)
+	for _, number := range forked {
+		isForked[number] = true
+	}
+
+	r := &fakeBlocksRepo{blocks: make(map[uint64]models.Block)}
+	for _, number := range numbers {
+		r.blocks[number] = models.Block{Number: number, Hash: header(number, isForked[number]).Hash().Hex()}
+	}
+
+	return r
+}
+
+func (r *fakeBlocksRepo) FindBlockByNumber(_ context.Context, number uint64) (*models.Block, error) {
+	block, ok := r.blocks[number]
+	if !ok {
+		return nil, nil
+	}
+
+	return &block, nil
+}
+
+func (r *fakeBlocksRepo) FindBlocksByCursor(_ context.Context, cursor uint64, limit int64) ([]models.Block, error) {
+	var out []models.Block
+	for number, block := range r.blocks {
+		if number <= cursor {
+			out = append(out, block)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Number > out[j].Number
+	})
+
+	if int64(len(out)) > limit {
+		out = out[:limit]
+	}
+
+	return out, nil
+}
+
+func TestFindCommonAncestor(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    []uint64
+		forked    []uint64
+		from      uint64
+		want      uint64
+		wantFound bool
+	}{
+		{name: "block is not orphaned", stored: []uint64{0, 1, 2, 3}, from: 3, want: 3, wantFound: true},
+		{name: "orphaned blocks", stored: []uint64{0, 1, 2, 3, 4, 5}, forked: []uint64{3, 4, 5}, from: 5, want: 2, wantFound: true},
+		{name: "gap below reorg point", stored: []uint64{0, 1, 4, 5}, forked: []uint64{4, 5}, from: 5, want: 1, wantFound: true},
+		{name: "orphaned block below gap", stored: []uint64{0, 1, 4, 5}, forked: []uint64{1, 4, 5}, from: 5, want: 0, wantFound: true},
+		{name: "start of reorg point is in gap", stored: []uint64{0, 1}, from: 5, want: 1, wantFound: true},
+		{name: "no block below gap", stored: []uint64{4, 5}, forked: []uint64{4, 5}, from: 5, wantFound: false},
+		{name: "all blocks are orphaned", stored: []uint64{0, 1, 2}, forked: []uint64{0, 1, 2}, from: 2, wantFound: false},
+	}
+
+	pool := dialChainNode(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Block{
+				blocksRepo:         newFakeBlocksRepo(tt.stored, tt.forked),
+				blockChainNodeConn: &entity.BlockChainNodeConnection{RPC: pool},
+			}
+
+			got, found, err := b.findCommonAncestor(context.Background(), tt.from)
+			if err != nil {
+				t.Fatalf("failed to find common ancestor : %s", err.Error())
+			}
+
+			if found != tt.wantFound || (found && got != tt.want) {
+				t.Fatalf("got [ ancestor : %d ] [ found : %v ], want [ ancestor : %d ] [ found : %v ]", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestFindOrphanedDescendant(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    []uint64
+		forked    []uint64
+		from      uint64
+		want      uint64
+		wantFound bool
+	}{
+		{name: "block is not orphaned", stored: []uint64{5, 6}, from: 5, wantFound: false},
+		{name: "block is not indexed", stored: []uint64{6}, forked: []uint64{6}, from: 5, wantFound: false},
+		{name: "orphaned blocks until canonical block", stored: []uint64{5, 6, 7, 8}, forked: []uint64{5, 6}, from: 5, want: 6, wantFound: true},
+		{name: "orphaned blocks until gap", stored: []uint64{5, 6, 8}, forked: []uint64{5, 6, 8}, from: 5, want: 6, wantFound: true},
+		{name: "blocks higher than canonical head", stored: []uint64{8, 9, 10, 11}, forked: []uint64{9, 10, 11}, from: 9, want: 11, wantFound: true},
+	}
+
+	pool := dialChainNode(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Block{
+				blocksRepo:         newFakeBlocksRepo(tt.stored, tt.forked),
+				blockChainNodeConn: &entity.BlockChainNodeConnection{RPC: pool},
+			}
+
+			got, found, err := b.findOrphanedDescendant(context.Background(), tt.from)
+			if err != nil {
+				t.Fatalf("failed to find orphaned descendant : %s", err.Error())
+			}
+
+			if found != tt.wantFound || (found && got != tt.want) {
+				t.Fatalf("got [ last : %d ] [ found : %v ], want [ last : %d ] [ found : %v ]", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
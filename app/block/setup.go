@@ -1,22 +1,53 @@
 package block
 
 import (
+	"go-evm-indexer/app/decoder"
 	"go-evm-indexer/app/queue"
+	"go-evm-indexer/config"
 	"go-evm-indexer/entity"
 	"go-evm-indexer/repository"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 type Block struct {
 	blockChainNodeConn *entity.BlockChainNodeConnection
 
-	blocksRepo       repository.IBlocksRepository
-	transactionsRepo repository.ITransactionsRepository
-	eventsRepo       repository.IEventsRepository
+	blocksRepo               repository.IBlocksRepository
+	transactionsRepo         repository.ITransactionsRepository
+	eventsRepo               repository.IEventsRepository
+	tokenTransfersRepo       repository.ITokenTransfersRepository
+	internalTransactionsRepo repository.IInternalTransactionsRepository
+	failedBlocksRepo         repository.IFailedBlocksRepository
+	syncStateRepo            repository.ISyncStateRepository
+	webhookSubscriptionsRepo repository.IWebhookSubscriptionsRepository
+	webhookDeliveriesRepo    repository.IWebhookDeliveriesRepository
+	balancesRepo             repository.IBalancesRepository
+	tokenBalancesRepo        repository.ITokenBalancesRepository
+	sinkMessagesRepo         repository.ISinkMessagesRepository
 
 	rollback repository.Rollback
 
+	registry *decoder.Registry
+	filter   *filter
+
+	// sink enables messages of indexed and rolled back blocks, those are stored in outbox and published by relay
+	sink bool
+	// webhooks enables webhook deliveries of indexed blocks, it is set only when listening to new blocks
+	// so that subscribers are not notified of historical blocks by backfill or migration
+	webhooks bool
+
+	// signer is used to recover sender of transactions
+	signer types.Signer
+	// blockReceiptsUnsupported is set to 1 when node doesn't support `eth_getBlockReceipts`
+	blockReceiptsUnsupported int32
+
 	status *entity.StateManager
 	queue  *queue.BlockProcessorQueue
+
+	// wg waits for background processes to stop when shutting down
+	wg sync.WaitGroup
 }
 
 func New(
@@ -25,16 +56,47 @@ func New(
 	blocksRepo repository.IBlocksRepository,
 	transactionsRepo repository.ITransactionsRepository,
 	eventsRepo repository.IEventsRepository,
+	tokenTransfersRepo repository.ITokenTransfersRepository,
+	internalTransactionsRepo repository.IInternalTransactionsRepository,
+	failedBlocksRepo repository.IFailedBlocksRepository,
+	syncStateRepo repository.ISyncStateRepository,
+	webhookSubscriptionsRepo repository.IWebhookSubscriptionsRepository,
+	webhookDeliveriesRepo repository.IWebhookDeliveriesRepository,
+	balancesRepo repository.IBalancesRepository,
+	tokenBalancesRepo repository.ITokenBalancesRepository,
+	sinkMessagesRepo repository.ISinkMessagesRepository,
 
 	rollback repository.Rollback,
+
+	registry *decoder.Registry,
+
+	sink bool,
 ) *Block {
 	return &Block{
 		blockChainNodeConn: blockChainNodeConn,
 
-		blocksRepo:       blocksRepo,
-		transactionsRepo: transactionsRepo,
-		eventsRepo:       eventsRepo,
+		blocksRepo:               blocksRepo,
+		transactionsRepo:         transactionsRepo,
+		eventsRepo:               eventsRepo,
+		tokenTransfersRepo:       tokenTransfersRepo,
+		internalTransactionsRepo: internalTransactionsRepo,
+		failedBlocksRepo:         failedBlocksRepo,
+		syncStateRepo:            syncStateRepo,
+		webhookSubscriptionsRepo: webhookSubscriptionsRepo,
+		webhookDeliveriesRepo:    webhookDeliveriesRepo,
+		balancesRepo:             balancesRepo,
+		tokenBalancesRepo:        tokenBalancesRepo,
+		sinkMessagesRepo:         sinkMessagesRepo,
 
 		rollback: rollback,
+
+		registry: registry,
+		filter:   newFilter(config.Get()),
+
+		sink: sink,
+
+		signer: types.LatestSignerForChainID(blockChainNodeConn.ChainID),
+
+		queue: queue.New(),
 	}
 }
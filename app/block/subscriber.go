@@ -0,0 +1,59 @@
+package block
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backoff of resubscribing to new block headers, it is doubled on every attempt until maximum backoff
+const (
+	resubscribeInitialBackoff = time.Second
+	resubscribeMaxBackoff     = time.Minute
+)
+
+// subscribeNewHead function that subscribes to new block headers by websocket, or by rpc in rpc subscribe mode
+func (b *Block) subscribeNewHead(ctx context.Context, options *ListenerOptions, headerChan chan *types.Header) (ethereum.Subscription, error) {
+	if options.IsRPCSubscribe {
+		return b.blockChainNodeConn.RPC.SubscribeNewHead(ctx, headerChan)
+	}
+
+	return b.blockChainNodeConn.Websocket.SubscribeNewHead(ctx, headerChan)
+}
+
+// resubscribe function that tries to subscribe to new block headers with backoff until it succeeds
+// or context is canceled, new subscription will be sent to channel
+func (b *Block) resubscribe(ctx context.Context, options *ListenerOptions, headerChan chan *types.Header, subsChan chan<- ethereum.Subscription) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		backoff := resubscribeInitialBackoff
+		for {
+			if !wait(ctx, backoff) {
+				return
+			}
+
+			subs, err := b.subscribeNewHead(ctx, options, headerChan)
+			if err != nil {
+				log.Printf("❌ failed to resubscribe to block headers, retry in %s : %s\n", backoff, err.Error())
+
+				if backoff *= 2; backoff > resubscribeMaxBackoff {
+					backoff = resubscribeMaxBackoff
+				}
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				subs.Unsubscribe()
+			case subsChan <- subs:
+			}
+
+			return
+		}
+	}()
+}
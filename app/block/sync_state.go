@@ -0,0 +1,186 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"log"
+	"time"
+)
+
+// prepareSyncState function that builds sync state from indexed blocks when it is empty,
+// it is needed only once for blocks that were indexed before sync state was added
+func (b *Block) prepareSyncState(ctx context.Context) error {
+	ranges, err := b.syncStateRepo.FindSyncRanges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find sync ranges from db : %w", err)
+	}
+
+	if len(ranges) > 0 {
+		return nil
+	}
+
+	latest, err := b.blocksRepo.FindLastestBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find latest block number from db : %w", err)
+	}
+
+	if latest == nil {
+		return nil
+	}
+
+	log.Printf("building sync state from indexed blocks [ to block : %d ]\n", latest.Number)
+
+	var (
+		current *models.SyncRange
+		step    uint64 = 1000
+	)
+
+	for i := uint64(0); i <= latest.Number; i += step {
+		start := time.Now()
+		blocks, err := b.blocksRepo.FindBlockByRange(ctx, i, i+step-1)
+		metrics.ObserveDB("find_block_by_range", start)
+		if err != nil {
+			return fmt.Errorf("failed to find block by range from db : %w", err)
+		}
+
+		for _, block := range blocks {
+			if current != nil && current.To+1 == block.Number {
+				current.To = block.Number
+				continue
+			}
+
+			if current != nil {
+				ranges = append(ranges, *current)
+			}
+			current = &models.SyncRange{From: block.Number, To: block.Number}
+		}
+	}
+
+	if current != nil {
+		ranges = append(ranges, *current)
+	}
+
+	for i := range ranges {
+		if err := b.syncStateRepo.AddSyncRange(ctx, &ranges[i]); err != nil {
+			return fmt.Errorf("failed to add sync range to db : %w", err)
+		}
+	}
+
+	log.Printf("✅ sync state is built [ ranges : %d ]\n", len(ranges))
+
+	return nil
+}
+
+// removeFromSyncState function that removes block numbers in range from sync state,
+// ranges that overlap with it will be split. It should be called in transaction
+func (b *Block) removeFromSyncState(ctx context.Context, from, to uint64) error {
+	overlapped, err := b.syncStateRepo.FindSyncRangesByRange(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to find sync ranges from db : %w", err)
+	}
+
+	var remaining []models.SyncRange
+	for _, syncRange := range overlapped {
+		if syncRange.From < from {
+			remaining = append(remaining, models.SyncRange{From: syncRange.From, To: from - 1})
+		}
+		if syncRange.To > to {
+			remaining = append(remaining, models.SyncRange{From: to + 1, To: syncRange.To})
+		}
+	}
+
+	if err := b.syncStateRepo.ReplaceSyncRanges(ctx, overlapped, remaining); err != nil {
+		return fmt.Errorf("failed to replace sync ranges in db : %w", err)
+	}
+
+	return nil
+}
+
+// maxCompactAttempts is number of attempts of compacting sync state when ranges are changed by others at the same time
+const maxCompactAttempts = 5
+
+// compactSyncState function that merges adjacent and overlapped ranges of sync state and returns all ranges sorted by from,
+// it is retried when ranges are changed by other transaction while compacting
+//
+// Every indexed block is added as a range of one block, so ranges need to be merged periodically
+func (b *Block) compactSyncState(ctx context.Context) ([]models.SyncRange, error) {
+	for attempt := 1; ; attempt++ {
+		merged, err := b.compactSyncStateOnce(ctx)
+		if !errors.Is(err, repository.ErrSyncStateChanged) || attempt == maxCompactAttempts || !wait(ctx, time.Duration(attempt)*50*time.Millisecond) {
+			return merged, err
+		}
+	}
+}
+
+// compactSyncStateOnce function that merges ranges of sync state in one transaction, ranges that are read
+// in transaction are locked by repository so that they are not changed by others until committed
+func (b *Block) compactSyncStateOnce(ctx context.Context) ([]models.SyncRange, error) {
+	var merged []models.SyncRange
+
+	err := b.rollback.ExecTransaction(ctx, func(sc context.Context) error {
+		ranges, err := b.syncStateRepo.FindSyncRanges(sc)
+		if err != nil {
+			return fmt.Errorf("failed to find sync ranges from db : %w", err)
+		}
+
+		merged = merged[:0]
+		for _, group := range groupSyncRanges(ranges) {
+			merged = append(merged, group.merged)
+
+			if len(group.ranges) > 1 {
+				if err := b.syncStateRepo.ReplaceSyncRanges(sc, group.ranges, []models.SyncRange{group.merged}); err != nil {
+					return fmt.Errorf("failed to replace sync ranges in db : %w", err)
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return merged, err
+}
+
+// syncRangeGroup ranges that are adjacent or overlapped with each other and the range that they are merged into
+type syncRangeGroup struct {
+	ranges []models.SyncRange
+	merged models.SyncRange
+}
+
+// groupSyncRanges function that groups ranges sorted by from, range that starts until the next block of
+// the highest end of group belongs to the group
+func groupSyncRanges(ranges []models.SyncRange) []syncRangeGroup {
+	var groups []syncRangeGroup
+	for i := 0; i < len(ranges); {
+		merged := ranges[i]
+
+		j := i + 1
+		for j < len(ranges) && ranges[j].From <= merged.To+1 {
+			// Range that is overlapped may end before the previous one
+			if ranges[j].To > merged.To {
+				merged.To = ranges[j].To
+			}
+			j++
+		}
+
+		groups = append(groups, syncRangeGroup{ranges: ranges[i:j], merged: merged})
+		i = j
+	}
+
+	return groups
+}
+
+// findGaps function that returns ranges of block numbers that are missing between sync ranges
+func findGaps(ranges []models.SyncRange) []models.SyncRange {
+	var gaps []models.SyncRange
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].From > ranges[i-1].To+1 {
+			gaps = append(gaps, models.SyncRange{From: ranges[i-1].To + 1, To: ranges[i].From - 1})
+		}
+	}
+
+	return gaps
+}
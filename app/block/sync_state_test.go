@@ -0,0 +1,152 @@
+package block
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"reflect"
+	"testing"
+)
+
+// fakeRollback runs function input without transaction
+type fakeRollback struct{}
+
+func (fakeRollback) ExecTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeSyncStateRepo struct {
+	repository.ISyncStateRepository
+	ranges []models.SyncRange
+	// changed is number of next calls of replacing ranges that fail as ranges have been changed by others
+	changed int
+}
+
+func (r *fakeSyncStateRepo) FindSyncRanges(_ context.Context) ([]models.SyncRange, error) {
+	return append([]models.SyncRange(nil), r.ranges...), nil
+}
+
+func (r *fakeSyncStateRepo) ReplaceSyncRanges(_ context.Context, oldRanges, newRanges []models.SyncRange) error {
+	if r.changed > 0 {
+		r.changed--
+		return repository.ErrSyncStateChanged
+	}
+
+	for _, old := range oldRanges {
+		for i, syncRange := range r.ranges {
+			if syncRange == old {
+				r.ranges = append(r.ranges[:i], r.ranges[i+1:]...)
+				break
+			}
+		}
+	}
+
+	// Ranges are kept sorted by from like repositories return them
+	for _, syncRange := range newRanges {
+		i := 0
+		for i < len(r.ranges) && r.ranges[i].From <= syncRange.From {
+			i++
+		}
+		r.ranges = append(r.ranges[:i], append([]models.SyncRange{syncRange}, r.ranges[i:]...)...)
+	}
+
+	return nil
+}
+
+func TestFindGaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []models.SyncRange
+		want   []models.SyncRange
+	}{
+		{name: "no ranges", ranges: nil, want: nil},
+		{name: "one range", ranges: []models.SyncRange{{From: 0, To: 10}}, want: nil},
+		{name: "adjacent ranges", ranges: []models.SyncRange{{From: 0, To: 4}, {From: 5, To: 10}}, want: nil},
+		{name: "gap of one block", ranges: []models.SyncRange{{From: 0, To: 4}, {From: 6, To: 10}}, want: []models.SyncRange{{From: 5, To: 5}}},
+		{
+			name:   "many gaps",
+			ranges: []models.SyncRange{{From: 0, To: 1}, {From: 5, To: 5}, {From: 9, To: 12}},
+			want:   []models.SyncRange{{From: 2, To: 4}, {From: 6, To: 8}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findGaps(tt.ranges); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactSyncState(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []models.SyncRange
+		changed int
+		want    []models.SyncRange
+		wantErr bool
+	}{
+		{name: "no ranges", ranges: nil, want: nil},
+		{
+			name:   "adjacent single blocks",
+			ranges: []models.SyncRange{{From: 1, To: 1}, {From: 2, To: 2}, {From: 3, To: 3}},
+			want:   []models.SyncRange{{From: 1, To: 3}},
+		},
+		{
+			name:   "gap is kept",
+			ranges: []models.SyncRange{{From: 1, To: 2}, {From: 3, To: 3}, {From: 5, To: 6}},
+			want:   []models.SyncRange{{From: 1, To: 3}, {From: 5, To: 6}},
+		},
+		{
+			name:   "overlapped range that ends before previous range",
+			ranges: []models.SyncRange{{From: 0, To: 100}, {From: 10, To: 20}, {From: 101, To: 101}},
+			want:   []models.SyncRange{{From: 0, To: 101}},
+		},
+		{
+			name:   "overlapped range is not followed by gap",
+			ranges: []models.SyncRange{{From: 0, To: 100}, {From: 10, To: 20}, {From: 50, To: 50}, {From: 102, To: 103}},
+			want:   []models.SyncRange{{From: 0, To: 100}, {From: 102, To: 103}},
+		},
+		{
+			name:    "retried when sync state is changed",
+			ranges:  []models.SyncRange{{From: 1, To: 1}, {From: 2, To: 2}},
+			changed: 2,
+			want:    []models.SyncRange{{From: 1, To: 2}},
+		},
+		{
+			name:    "failed when sync state keeps changing",
+			ranges:  []models.SyncRange{{From: 1, To: 1}, {From: 2, To: 2}},
+			changed: maxCompactAttempts,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSyncStateRepo{ranges: tt.ranges, changed: tt.changed}
+			b := &Block{syncStateRepo: repo, rollback: fakeRollback{}}
+
+			got, err := b.compactSyncState(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to compact sync state : %s", err.Error())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			// Stored ranges are replaced by merged ranges
+			if !reflect.DeepEqual(repo.ranges, tt.want) {
+				t.Fatalf("got stored ranges %v, want %v", repo.ranges, tt.want)
+			}
+		})
+	}
+}
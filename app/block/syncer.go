@@ -11,142 +11,153 @@ import (
 	"github.com/gammazero/workerpool"
 )
 
+// syncBatchSize is number of block numbers that are checked and submitted at once,
+// next batch is submitted after all jobs of batch are completed
+const syncBatchSize = 1000
+
 // sync function that fetches block by a specified range of input & attempts to fetch
-// missing blocks in that range.
+// missing blocks in that range. indexed is called with number of blocks of each batch
+// that are indexed already, it can be nil.
 //
-// this process will wait for all of them to complete
-func (b *Block) sync(from, to uint64, jb func(wp *workerpool.WorkerPool, j *entity.Job)) {
+// this process will wait for all of them to complete, it stops submitting jobs when context is canceled
+func (b *Block) sync(ctx context.Context, from, to uint64, jb func(wp *workerpool.WorkerPool, j *entity.Job), indexed func(n uint64)) {
 	log.Printf("starting sync block from [ block : %d ] to [ block : %d ]\n", from, to)
-	var ctx = context.Background()
 
 	if to < from {
 		log.Println("❌ unexpected!!! 'to' over than 'from' when running sync process")
 		return
 	}
 
-	wp := workerpool.New(runtime.NumCPU() * int(config.Get().Concurrency))
-	defer wp.StopWait()
-
-	job := func(num uint64) {
-		jb(wp, &entity.Job{
-			BlockNumber: num,
-		})
-	}
-
-	var step uint64 = 1000
-
-	for i := from; i <= to; i += step {
-		toExpected := i + step
-		if toExpected > to {
+	for i := from; ctx.Err() == nil; i += syncBatchSize {
+		toExpected := i + syncBatchSize - 1
+		// Batch is also ended at the end of range when it is overflowed
+		if toExpected > to || toExpected < i {
 			toExpected = to
 		}
 
-		blocks, err := b.blocksRepo.FindBlockByRange(ctx, i, toExpected)
+		missing, err := b.findMissingBlocks(ctx, i, toExpected)
 		if err != nil {
-			log.Printf("❌ failed to find block by range [ from : %d ] [ to : %d ] from db : %s\n", i, toExpected, err.Error())
-			continue
-		}
-
-		// There are not any blocks of this range system that will run all jobs
-		if len(blocks) == 0 {
-			for number := i; number <= toExpected; number++ {
-				job(number)
-				<-time.After(time.Duration(100) * time.Millisecond)
+			log.Printf("❌ %s\n", err.Error())
+		} else {
+			if indexed != nil {
+				indexed(toExpected - i + 1 - uint64(len(missing)))
 			}
 
-			continue
-		}
-
-		// There are all blocks of this range it will skip to next range
-		if toExpected-i == uint64(len(blocks)) {
-			continue
+			// Some blocks are missing in range, job process is run for them
+			wp := workerpool.New(runtime.NumCPU() * int(config.Get().Concurrency))
+			for _, number := range missing {
+				jb(wp, &entity.Job{
+					BlockNumber: number,
+				})
+			}
+			wp.StopWait()
 		}
 
-		// Some blocks are missing in range, attempting to find them
-		// and run job process
-		for _, missingNumber := range findMissingBlocksInRange(blocks, i, toExpected) {
-			job(missingNumber)
-			<-time.After(time.Duration(100) * time.Millisecond)
+		if toExpected == to {
+			return
 		}
 	}
-
 }
 
 // syncBlocksByRange function that sync blocks according to the specified range.
-func (b *Block) syncBlocksByRange(from, to uint64) {
-	b.sync(from, to, b.job())
+func (b *Block) syncBlocksByRange(ctx context.Context, from, to uint64) {
+	b.sync(ctx, from, to, b.job(ctx), nil)
 
 	// Once completed the first iteration of processing blocks
 	// The system will run background to check there are any missing blocks
-	go b.syncMissingBlocks()
+	b.syncMissingBlocks(ctx)
 }
 
-// syncMissingBlocks function that ticker every 1 minute for check missing blocks from database &
-// fetches missing blocks
-func (b *Block) syncMissingBlocks() {
+// syncMissingBlocks function that ticker every 1 minute for check gaps between indexed ranges in sync state &
+// fetches missing blocks of those gaps until context is canceled
+func (b *Block) syncMissingBlocks(ctx context.Context) {
 	log.Println("starting sync missing block")
 
-	for {
-		var (
-			ctx           = context.Background()
-			latestBlockNo = uint64(0)
-		)
-
-		block, err := b.blocksRepo.FindLastestBlock(ctx)
+	for ctx.Err() == nil {
+		ranges, err := b.compactSyncState(ctx)
 		if err != nil {
-			log.Printf("❌ failed to find latest block number from db : %s\n", err.Error())
+			log.Printf("❌ %s\n", err.Error())
+			wait(ctx, time.Duration(1)*time.Minute)
 			continue
 		}
 
-		if block != nil {
-			latestBlockNo = block.Number
-		}
-
-		blockCount, err := b.blocksRepo.CountBlocks(ctx)
-		if err != nil {
-			log.Printf("❌ failed to count block from db : %s\n", err.Error())
-			continue
-		}
-
-		if latestBlockNo+1 == blockCount {
+		gaps := findGaps(ranges)
+		if len(gaps) == 0 {
 			log.Println("no missing blocks found")
 
-			<-time.After(time.Duration(1) * time.Minute)
+			wait(ctx, time.Duration(1)*time.Minute)
 			continue
 		}
 
-		log.Printf("[%d] missing blocks found\n", latestBlockNo+1-blockCount)
+		log.Printf("[%d] gaps of missing blocks found\n", len(gaps))
+
+		// Only blocks inside gaps are fetched, indexed ranges are not scanned again
+		for _, gap := range gaps {
+			if ctx.Err() != nil {
+				return
+			}
 
-		// This case mean block in DB not matched with latest block number, attempting to find
-		// missing blocks by finding from zero to latest block number again
-		b.sync(0, latestBlockNo, b.job())
+			b.sync(ctx, gap.From, gap.To, b.job(ctx), nil)
+		}
 
-		<-time.After(time.Duration(1) * time.Minute)
+		wait(ctx, time.Duration(1)*time.Minute)
 	}
 }
 
-func (b *Block) job() func(wp *workerpool.WorkerPool, j *entity.Job) {
+// job function that returns job of fetching block, job that has not been started
+// will be skipped when root context is canceled
+func (b *Block) job(rootCtx context.Context) func(wp *workerpool.WorkerPool, j *entity.Job) {
 	return func(wp *workerpool.WorkerPool, j *entity.Job) {
 		wp.Submit(func() {
-			var ctx, cancel = context.WithTimeout(context.Background(), time.Duration(config.Get().MaxJobTimeout)*time.Minute)
-			defer cancel()
+			b.runJob(rootCtx, j.BlockNumber)
+		})
+	}
+}
 
-			block, err := b.blocksRepo.FindBlockByNumber(ctx, j.BlockNumber)
-			if err != nil {
-				log.Printf("❌ failed to find block by number from db : %s\n", err.Error())
-				return
-			}
+// runJob function that fetches block if it is not indexed or failed, it is skipped when root context is canceled
+func (b *Block) runJob(rootCtx context.Context, number uint64) {
+	if rootCtx.Err() != nil {
+		return
+	}
 
-			// Already have this block number in db
-			if block != nil {
-				return
-			}
+	// Running job is not canceled by shutting down, it will be completed or reached timeout
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Duration(config.Get().MaxJobTimeout)*time.Minute)
+	defer cancel()
 
-			if !b.fetchBlockByNumber(ctx, j.BlockNumber) {
-				// If cannot fetch block it will put block number to queue
-				// to process next round
-				b.queue.Put(j.BlockNumber)
-			}
-		})
+	block, err := b.blocksRepo.FindBlockByNumber(ctx, number)
+	if err != nil {
+		log.Printf("❌ failed to find block by number from db : %s\n", err.Error())
+		return
+	}
+
+	// Already have this block number in db
+	if block != nil {
+		return
+	}
+
+	// Failed block will not be processed until it is requeued
+	failed, err := b.failedBlocksRepo.FindFailedBlockByNumber(ctx, number)
+	if err != nil {
+		log.Printf("❌ failed to find failed block by number from db : %s\n", err.Error())
+		return
+	}
+	if failed != nil {
+		return
+	}
+
+	if err := b.fetchBlockByNumber(ctx, number); err != nil {
+		// If cannot fetch block it will put block number to queue
+		// to process next round
+		b.handleFailedBlock(number, err)
+	}
+}
+
+// wait function that waits for duration, it returns false if context is canceled before duration
+func wait(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
 	}
 }
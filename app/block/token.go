@@ -0,0 +1,115 @@
+package block
+
+import (
+	"go-evm-indexer/models"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// Transfer(address,address,uint256) of ERC-20 and ERC-721
+	transferEventSignature = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// TransferSingle(address,address,address,uint256,uint256) of ERC-1155
+	transferSingleEventSignature = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	// TransferBatch(address,address,address,uint256[],uint256[]) of ERC-1155
+	transferBatchEventSignature = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+
+	transferBatchArguments abi.Arguments
+)
+
+func init() {
+	uint256Array, err := abi.NewType("uint256[]", "", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	transferBatchArguments = abi.Arguments{
+		{Name: "ids", Type: uint256Array},
+		{Name: "values", Type: uint256Array},
+	}
+}
+
+// extractTokenTransfers function that find standard token transfers from event logs
+//
+// `Transfer` of ERC-20 and ERC-721 have same signature, they are distinguished by number of indexed arguments.
+// `TransferBatch` of ERC-1155 will be split to transfer per token id
+func extractTokenTransfers(logs []*types.Log) []*models.TokenTransfer {
+	var transfers []*models.TokenTransfer
+
+	for _, l := range logs {
+		if len(l.Topics) == 0 {
+			continue
+		}
+
+		base := models.TokenTransfer{
+			BlockHash:       l.BlockHash.Hex(),
+			BlockNumber:     l.BlockNumber,
+			TransactionHash: l.TxHash.Hex(),
+			LogIndex:        l.Index,
+			Token:           l.Address.Hex(),
+		}
+
+		switch {
+		case l.Topics[0] == transferEventSignature && len(l.Topics) == 3 && len(l.Data) == 32:
+			transfer := base
+			transfer.Standard = models.TokenStandardERC20
+			transfer.From = topicToAddress(l.Topics[1])
+			transfer.To = topicToAddress(l.Topics[2])
+			transfer.Amount = new(big.Int).SetBytes(l.Data).String()
+			transfers = append(transfers, &transfer)
+
+		case l.Topics[0] == transferEventSignature && len(l.Topics) == 4 && len(l.Data) == 0:
+			transfer := base
+			transfer.Standard = models.TokenStandardERC721
+			transfer.From = topicToAddress(l.Topics[1])
+			transfer.To = topicToAddress(l.Topics[2])
+			transfer.Amount = "1"
+			transfer.TokenID = l.Topics[3].Big().String()
+			transfers = append(transfers, &transfer)
+
+		case l.Topics[0] == transferSingleEventSignature && len(l.Topics) == 4 && len(l.Data) == 64:
+			transfer := base
+			transfer.Standard = models.TokenStandardERC1155
+			transfer.Operator = topicToAddress(l.Topics[1])
+			transfer.From = topicToAddress(l.Topics[2])
+			transfer.To = topicToAddress(l.Topics[3])
+			transfer.TokenID = new(big.Int).SetBytes(l.Data[:32]).String()
+			transfer.Amount = new(big.Int).SetBytes(l.Data[32:]).String()
+			transfers = append(transfers, &transfer)
+
+		case l.Topics[0] == transferBatchEventSignature && len(l.Topics) == 4:
+			values, err := transferBatchArguments.UnpackValues(l.Data)
+			if err != nil {
+				continue
+			}
+
+			ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+			if len(ids) != len(amounts) {
+				continue
+			}
+
+			for i := range ids {
+				transfer := base
+				transfer.BatchIndex = uint(i)
+				transfer.Standard = models.TokenStandardERC1155
+				transfer.Operator = topicToAddress(l.Topics[1])
+				transfer.From = topicToAddress(l.Topics[2])
+				transfer.To = topicToAddress(l.Topics[3])
+				transfer.TokenID = ids[i].String()
+				transfer.Amount = amounts[i].String()
+				transfers = append(transfers, &transfer)
+			}
+		}
+	}
+
+	return transfers
+}
+
+// topicToAddress function that convert indexed address argument of topic to hex string of address
+func topicToAddress(topic common.Hash) string {
+	return common.BytesToAddress(topic.Bytes()).Hex()
+}
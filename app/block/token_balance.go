@@ -0,0 +1,179 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"log"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tokenBalanceKey token and holder of token balance
+type tokenBalanceKey struct {
+	token  string
+	holder string
+}
+
+// tokenBalanceDeltas function that returns changes of ERC-20 token balances by transfers sorted by token and holder,
+// amounts are negated when transfers are reverted. Balance of zero address is not changed by mint and burn
+//
+// Block number of delta is the latest block number of transfers, or the block before reverted transfers
+func tokenBalanceDeltas(transfers []*models.TokenTransfer, revert bool) ([]*models.TokenBalance, error) {
+	var (
+		zero   = common.Address{}.Hex()
+		deltas = make(map[tokenBalanceKey]*models.TokenBalance)
+		sums   = make(map[tokenBalanceKey]*big.Int)
+	)
+
+	add := func(token, holder string, amount *big.Int, number uint64) {
+		if holder == zero {
+			return
+		}
+
+		key := tokenBalanceKey{token: token, holder: holder}
+		delta, ok := deltas[key]
+		if !ok {
+			delta = &models.TokenBalance{Token: token, Holder: holder, BlockNumber: number}
+			deltas[key] = delta
+			sums[key] = new(big.Int)
+		}
+
+		if (revert && number < delta.BlockNumber) || (!revert && number > delta.BlockNumber) {
+			delta.BlockNumber = number
+		}
+		sums[key].Add(sums[key], amount)
+	}
+
+	for _, transfer := range transfers {
+		if transfer.Standard != models.TokenStandardERC20 {
+			continue
+		}
+
+		amount, ok := new(big.Int).SetString(transfer.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount of token transfer [ tx : %s ] : %s", transfer.TransactionHash, transfer.Amount)
+		}
+
+		number := transfer.BlockNumber
+		if revert {
+			amount.Neg(amount)
+			if number > 0 {
+				number--
+			}
+		}
+
+		add(transfer.Token, transfer.From, new(big.Int).Neg(amount), number)
+		add(transfer.Token, transfer.To, amount, number)
+	}
+
+	out := make([]*models.TokenBalance, 0, len(deltas))
+	for key, delta := range deltas {
+		delta.Balance = sums[key].String()
+		out = append(out, delta)
+	}
+
+	// Balances are updated in the same order to prevent deadlock between concurrent transactions
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Token != out[j].Token {
+			return out[i].Token < out[j].Token
+		}
+		return out[i].Holder < out[j].Holder
+	})
+
+	return out, nil
+}
+
+// applyTokenTransfers function that updates token balances by transfers, it should be called in transaction
+func (b *Block) applyTokenTransfers(ctx context.Context, transfers []*models.TokenTransfer, revert bool) error {
+	deltas, err := tokenBalanceDeltas(transfers, revert)
+	if err != nil {
+		return err
+	}
+
+	if err := b.tokenBalancesRepo.AddTokenBalanceDeltas(ctx, deltas); err != nil {
+		return fmt.Errorf("failed to update token balances in db : %w", err)
+	}
+
+	return nil
+}
+
+// revertTokenBalances function that reverses updates of token balances by transfers of block, it should be called in transaction
+// before token transfers of block are deleted
+func (b *Block) revertTokenBalances(ctx context.Context, blockHash common.Hash) error {
+	transfers, err := b.tokenTransfersRepo.FindTokenTransfersByBlockHash(ctx, blockHash)
+	if err != nil {
+		return fmt.Errorf("failed to find token transfers by block hash from db : %w", err)
+	}
+
+	return b.applyTokenTransfers(ctx, toPointers(transfers), true)
+}
+
+// RebuildTokenBalances function that regenerates token balances from stored token transfers into staging
+// and then replaces all token balances by them, so balances are never left half rebuilt by a crash.
+// Every batch of transfers is applied in its own short transaction, an interrupted rebuild is started again
+// from the beginning. Indexer must be stopped while rebuilding, otherwise its updates of balances are lost
+func (b *Block) RebuildTokenBalances(ctx context.Context) error {
+	if err := b.tokenBalancesRepo.ResetStagedTokenBalances(ctx); err != nil {
+		return fmt.Errorf("failed to reset staged token balances in db : %s", err.Error())
+	}
+
+	var (
+		cursor       = &repository.TokenTransferCursor{}
+		limit  int64 = 1000
+		total  int
+	)
+
+	for {
+		transfers, err := b.tokenTransfersRepo.FindTokenTransfersByStandard(ctx, models.TokenStandardERC20, cursor, limit)
+		if err != nil {
+			return fmt.Errorf("failed to find token transfers from db : %s", err.Error())
+		}
+
+		if len(transfers) == 0 {
+			break
+		}
+
+		deltas, err := tokenBalanceDeltas(toPointers(transfers), false)
+		if err != nil {
+			return err
+		}
+
+		err = b.rollback.ExecTransaction(ctx, func(sc context.Context) error {
+			if err := b.tokenBalancesRepo.AddStagedTokenBalanceDeltas(sc, deltas); err != nil {
+				return fmt.Errorf("failed to update staged token balances in db : %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		total += len(transfers)
+		last := transfers[len(transfers)-1]
+		cursor = &repository.TokenTransferCursor{BlockNumber: last.BlockNumber, LogIndex: last.LogIndex, BatchIndex: last.BatchIndex + 1}
+
+		log.Printf("rebuilding token balances [ transfers : %d ] [ block : %d ]\n", total, last.BlockNumber)
+	}
+
+	if err := b.tokenBalancesRepo.SwapStagedTokenBalances(ctx); err != nil {
+		return fmt.Errorf("failed to replace token balances by staged token balances in db : %s", err.Error())
+	}
+
+	log.Printf("✅ token balances are rebuilt [ transfers : %d ]\n", total)
+
+	return nil
+}
+
+func toPointers(transfers []models.TokenTransfer) []*models.TokenTransfer {
+	out := make([]*models.TokenTransfer, len(transfers))
+	for i := range transfers {
+		out[i] = &transfers[i]
+	}
+
+	return out
+}
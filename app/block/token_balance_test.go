@@ -0,0 +1,102 @@
+package block
+
+import (
+	"go-evm-indexer/models"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestTokenBalanceDeltas(t *testing.T) {
+	var (
+		zero   = common.Address{}.Hex()
+		tokenA = common.HexToAddress("0xa").Hex()
+		tokenB = common.HexToAddress("0xb").Hex()
+		alice  = common.HexToAddress("0x1").Hex()
+		bob    = common.HexToAddress("0x2").Hex()
+	)
+
+	erc20 := func(token, from, to, amount string, number uint64) *models.TokenTransfer {
+		return &models.TokenTransfer{Standard: models.TokenStandardERC20, Token: token, From: from, To: to, Amount: amount, BlockNumber: number}
+	}
+
+	tests := []struct {
+		name      string
+		transfers []*models.TokenTransfer
+		revert    bool
+		want      []models.TokenBalance
+		wantErr   bool
+	}{
+		{name: "no transfers", want: []models.TokenBalance{}},
+		{
+			name:      "mint is not counted for zero address",
+			transfers: []*models.TokenTransfer{erc20(tokenA, zero, alice, "100", 5)},
+			want:      []models.TokenBalance{{Token: tokenA, Holder: alice, Balance: "100", BlockNumber: 5}},
+		},
+		{
+			name: "transfers are summed by token and holder with latest block number",
+			transfers: []*models.TokenTransfer{
+				erc20(tokenB, alice, bob, "10", 6),
+				erc20(tokenA, alice, bob, "30", 7),
+				erc20(tokenA, bob, alice, "5", 5),
+			},
+			want: []models.TokenBalance{
+				{Token: tokenA, Holder: alice, Balance: "-25", BlockNumber: 7},
+				{Token: tokenA, Holder: bob, Balance: "25", BlockNumber: 7},
+				{Token: tokenB, Holder: alice, Balance: "-10", BlockNumber: 6},
+				{Token: tokenB, Holder: bob, Balance: "10", BlockNumber: 6},
+			},
+		},
+		{
+			name: "reverted transfers are negated at block before earliest transfer",
+			transfers: []*models.TokenTransfer{
+				erc20(tokenA, alice, bob, "30", 7),
+				erc20(tokenA, alice, zero, "5", 6),
+			},
+			revert: true,
+			want: []models.TokenBalance{
+				{Token: tokenA, Holder: alice, Balance: "35", BlockNumber: 5},
+				{Token: tokenA, Holder: bob, Balance: "-30", BlockNumber: 6},
+			},
+		},
+		{
+			name: "transfers of other standards are skipped",
+			transfers: []*models.TokenTransfer{
+				{Standard: models.TokenStandardERC721, Token: tokenA, From: alice, To: bob, Amount: "1", TokenID: "1"},
+				{Standard: models.TokenStandardERC1155, Token: tokenA, From: alice, To: bob, Amount: "3", TokenID: "1"},
+			},
+			want: []models.TokenBalance{},
+		},
+		{
+			name:      "invalid amount",
+			transfers: []*models.TokenTransfer{erc20(tokenA, alice, bob, "0x1", 5)},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deltas, err := tokenBalanceDeltas(tt.transfers, tt.revert)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error, want error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to compute deltas : %s", err.Error())
+			}
+
+			got := []models.TokenBalance{}
+			for _, delta := range deltas {
+				got = append(got, *delta)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
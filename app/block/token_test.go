@@ -0,0 +1,120 @@
+package block
+
+import (
+	"go-evm-indexer/models"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestExtractTokenTransfers(t *testing.T) {
+	var (
+		token    = common.HexToAddress("0x10")
+		operator = common.HexToAddress("0x1")
+		from     = common.HexToAddress("0x2")
+		to       = common.HexToAddress("0x3")
+	)
+
+	word := func(n int64) []byte {
+		return common.BigToHash(big.NewInt(n)).Bytes()
+	}
+
+	batchData, err := transferBatchArguments.Pack([]*big.Int{big.NewInt(7), big.NewInt(8)}, []*big.Int{big.NewInt(70), big.NewInt(80)})
+	if err != nil {
+		t.Fatalf("failed to pack batch transfer : %s", err.Error())
+	}
+
+	mismatchedData, err := transferBatchArguments.Pack([]*big.Int{big.NewInt(7)}, []*big.Int{big.NewInt(70), big.NewInt(80)})
+	if err != nil {
+		t.Fatalf("failed to pack batch transfer : %s", err.Error())
+	}
+
+	addressTopic := func(address common.Address) common.Hash {
+		return common.BytesToHash(address.Bytes())
+	}
+
+	tests := []struct {
+		name string
+		log  *types.Log
+		want []models.TokenTransfer
+	}{
+		{
+			name: "erc20 transfer",
+			log:  &types.Log{Topics: []common.Hash{transferEventSignature, addressTopic(from), addressTopic(to)}, Data: word(100)},
+			want: []models.TokenTransfer{{Standard: models.TokenStandardERC20, From: from.Hex(), To: to.Hex(), Amount: "100"}},
+		},
+		{
+			name: "erc721 transfer",
+			log:  &types.Log{Topics: []common.Hash{transferEventSignature, addressTopic(from), addressTopic(to), common.BigToHash(big.NewInt(9))}},
+			want: []models.TokenTransfer{{Standard: models.TokenStandardERC721, From: from.Hex(), To: to.Hex(), Amount: "1", TokenID: "9"}},
+		},
+		{
+			name: "erc1155 single transfer",
+			log: &types.Log{
+				Topics: []common.Hash{transferSingleEventSignature, addressTopic(operator), addressTopic(from), addressTopic(to)},
+				Data:   append(word(5), word(50)...),
+			},
+			want: []models.TokenTransfer{{Standard: models.TokenStandardERC1155, Operator: operator.Hex(), From: from.Hex(), To: to.Hex(), TokenID: "5", Amount: "50"}},
+		},
+		{
+			name: "erc1155 batch transfer is split by token id",
+			log: &types.Log{
+				Topics: []common.Hash{transferBatchEventSignature, addressTopic(operator), addressTopic(from), addressTopic(to)},
+				Data:   batchData,
+			},
+			want: []models.TokenTransfer{
+				{Standard: models.TokenStandardERC1155, Operator: operator.Hex(), From: from.Hex(), To: to.Hex(), TokenID: "7", Amount: "70", BatchIndex: 0},
+				{Standard: models.TokenStandardERC1155, Operator: operator.Hex(), From: from.Hex(), To: to.Hex(), TokenID: "8", Amount: "80", BatchIndex: 1},
+			},
+		},
+		{
+			name: "batch transfer with mismatched lengths",
+			log: &types.Log{
+				Topics: []common.Hash{transferBatchEventSignature, addressTopic(operator), addressTopic(from), addressTopic(to)},
+				Data:   mismatchedData,
+			},
+		},
+		{
+			name: "batch transfer with invalid data",
+			log:  &types.Log{Topics: []common.Hash{transferBatchEventSignature, addressTopic(operator), addressTopic(from), addressTopic(to)}, Data: word(1)},
+		},
+		{
+			name: "transfer with unexpected data length",
+			log:  &types.Log{Topics: []common.Hash{transferEventSignature, addressTopic(from), addressTopic(to)}, Data: append(word(1), word(2)...)},
+		},
+		{
+			name: "other event",
+			log:  &types.Log{Topics: []common.Hash{common.HexToHash("0x4")}, Data: word(1)},
+		},
+		{
+			name: "anonymous event",
+			log:  &types.Log{Data: word(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.log.Address = token
+			tt.log.Index = 3
+
+			got := extractTokenTransfers([]*types.Log{tt.log})
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transfers, want %d", len(got), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				// Fields of log are the same for all transfers
+				want.Token = token.Hex()
+				want.LogIndex = 3
+				want.BlockHash = common.Hash{}.Hex()
+				want.TransactionHash = common.Hash{}.Hex()
+
+				if *got[i] != want {
+					t.Fatalf("got %+v, want %+v", *got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/models"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// callFrame call frame of result of `callTracer`
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Error   string          `json:"error"`
+	Calls   []callFrame     `json:"calls"`
+}
+
+// traceResult result of each transaction from `debug_traceBlockByNumber`
+type traceResult struct {
+	Result *callFrame `json:"result"`
+	Error  string     `json:"error"`
+}
+
+// fetchInternalTransactions function that trace all transactions of block by `callTracer`
+// and flatten nested call frames to internal transactions, internal transactions are grouped by transaction index
+func (b *Block) fetchInternalTransactions(ctx context.Context, block *types.Block) ([][]*models.InternalTransaction, error) {
+	var results []traceResult
+
+	start := time.Now()
+	err := b.blockChainNodeConn.RPC.CallContext(ctx, &results, "debug_traceBlockByNumber", hexutil.EncodeBig(block.Number()), map[string]string{
+		"tracer": "callTracer",
+	})
+	metrics.ObserveRPC("debug_traceBlockByNumber", start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace block [ block : %d ] : %s", block.NumberU64(), err.Error())
+	}
+
+	if len(results) != block.Transactions().Len() {
+		return nil, fmt.Errorf("number of traces [%d] is not matched with number of transactions [%d]", len(results), block.Transactions().Len())
+	}
+
+	out := make([][]*models.InternalTransaction, len(results))
+	for i, result := range results {
+		tx := block.Transactions()[i]
+		if result.Result == nil {
+			return nil, fmt.Errorf("failed to trace transaction [ tx : %s ] : %s", tx.Hash().Hex(), result.Error)
+		}
+
+		var index uint
+		flattenCallFrames(block, tx.Hash(), result.Result.Calls, 1, result.Result.Error != "", &index, &out[i])
+	}
+
+	return out, nil
+}
+
+// flattenCallFrames function that append call frames and their nested call frames to internal transactions
+// by depth-first order
+func flattenCallFrames(block *types.Block, txHash common.Hash, frames []callFrame, depth uint, reverted bool, index *uint, out *[]*models.InternalTransaction) {
+	for _, frame := range frames {
+		var (
+			to    string
+			value = big.NewInt(0)
+		)
+
+		if frame.To != nil {
+			to = frame.To.Hex()
+		}
+		if frame.Value != nil {
+			value = frame.Value.ToInt()
+		}
+
+		frameReverted := reverted || frame.Error != ""
+
+		*out = append(*out, &models.InternalTransaction{
+			BlockHash:       block.Hash().Hex(),
+			BlockNumber:     block.NumberU64(),
+			TransactionHash: txHash.Hex(),
+			Index:           *index,
+			Depth:           depth,
+			Type:            frame.Type,
+			From:            frame.From.Hex(),
+			To:              to,
+			Value:           value.String(),
+			Gas:             uint64(frame.Gas),
+			GasUsed:         uint64(frame.GasUsed),
+			Error:           frame.Error,
+			Reverted:        frameReverted,
+		})
+		*index++
+
+		flattenCallFrames(block, txHash, frame.Calls, depth+1, frameReverted, index, out)
+	}
+}
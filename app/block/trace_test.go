@@ -0,0 +1,115 @@
+package block
+
+import (
+	"encoding/json"
+	"go-evm-indexer/models"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestFlattenCallFrames(t *testing.T) {
+	var (
+		a = common.HexToAddress("0x1").Hex()
+		b = common.HexToAddress("0x2").Hex()
+		c = common.HexToAddress("0x3").Hex()
+	)
+
+	// frame is flattened internal transaction without fields of block and transaction
+	type frame struct {
+		Index    uint
+		Depth    uint
+		Type     string
+		From     string
+		To       string
+		Value    string
+		Reverted bool
+	}
+
+	tests := []struct {
+		name  string
+		trace string
+		want  []frame
+	}{
+		{
+			name:  "no calls",
+			trace: `{"type":"CALL","from":"` + a + `","to":"` + b + `"}`,
+		},
+		{
+			name: "nested calls by depth-first order",
+			trace: `{"type":"CALL","from":"` + a + `","to":"` + b + `","calls":[
+				{"type":"CALL","from":"` + b + `","to":"` + c + `","value":"0x10","calls":[
+					{"type":"STATICCALL","from":"` + c + `","to":"` + a + `"}
+				]},
+				{"type":"CREATE","from":"` + b + `","to":"` + c + `","value":"0x0"}
+			]}`,
+			want: []frame{
+				{Index: 0, Depth: 1, Type: "CALL", From: b, To: c, Value: "16"},
+				{Index: 1, Depth: 2, Type: "STATICCALL", From: c, To: a, Value: "0"},
+				{Index: 2, Depth: 1, Type: "CREATE", From: b, To: c, Value: "0"},
+			},
+		},
+		{
+			name: "calls under reverted call are reverted",
+			trace: `{"type":"CALL","from":"` + a + `","to":"` + b + `","calls":[
+				{"type":"CALL","from":"` + b + `","to":"` + c + `","error":"execution reverted","calls":[
+					{"type":"CALL","from":"` + c + `","to":"` + a + `"}
+				]},
+				{"type":"CALL","from":"` + b + `","to":"` + a + `"}
+			]}`,
+			want: []frame{
+				{Index: 0, Depth: 1, Type: "CALL", From: b, To: c, Value: "0", Reverted: true},
+				{Index: 1, Depth: 2, Type: "CALL", From: c, To: a, Value: "0", Reverted: true},
+				{Index: 2, Depth: 1, Type: "CALL", From: b, To: a, Value: "0"},
+			},
+		},
+		{
+			name:  "all calls of reverted transaction are reverted",
+			trace: `{"type":"CALL","from":"` + a + `","to":"` + b + `","error":"out of gas","calls":[{"type":"SELFDESTRUCT","from":"` + b + `"}]}`,
+			want: []frame{
+				{Index: 0, Depth: 1, Type: "SELFDESTRUCT", From: b, To: "", Value: "0", Reverted: true},
+			},
+		},
+	}
+
+	block := types.NewBlockWithHeader(&types.Header{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var root callFrame
+			if err := json.Unmarshal([]byte(tt.trace), &root); err != nil {
+				t.Fatalf("failed to decode trace : %s", err.Error())
+			}
+
+			var (
+				index uint
+				out   []*models.InternalTransaction
+			)
+			// Calls of transaction are reverted when transaction itself is failed
+			flattenCallFrames(block, common.Hash{1}, root.Calls, 1, root.Error != "", &index, &out)
+
+			var got []frame
+			for _, internalTx := range out {
+				if internalTx.TransactionHash != (common.Hash{1}).Hex() {
+					t.Fatalf("got transaction hash %s", internalTx.TransactionHash)
+				}
+
+				got = append(got, frame{
+					Index:    internalTx.Index,
+					Depth:    internalTx.Depth,
+					Type:     internalTx.Type,
+					From:     internalTx.From,
+					To:       internalTx.To,
+					Value:    internalTx.Value,
+					Reverted: internalTx.Reverted,
+				})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
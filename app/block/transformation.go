@@ -1,7 +1,9 @@
 package block
 
 import (
+	"go-evm-indexer/app/decoder"
 	"go-evm-indexer/models"
+	"math/big"
 
 	c "go-evm-indexer/app/common"
 
@@ -10,8 +12,18 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
-// transformTransaction change block of go-ethereum to a given format
-func transformBlock(block *types.Block) *models.Block {
+// transformBlock change block of go-ethereum to a given format, total difficulty is nil if node doesn't return it
+func transformBlock(block *types.Block, totalDifficulty *big.Int) *models.Block {
+	baseFee := ""
+	if block.BaseFee() != nil {
+		baseFee = block.BaseFee().String()
+	}
+
+	td := ""
+	if totalDifficulty != nil {
+		td = totalDifficulty.String()
+	}
+
 	return &models.Block{
 		Hash:                block.Hash().Hex(),
 		Number:              block.NumberU64(),
@@ -28,31 +40,53 @@ func transformBlock(block *types.Block) *models.Block {
 		TransactionRootHash: block.TxHash().Hex(),
 		ReceiptRootHash:     block.ReceiptHash().Hex(),
 		ExtraData:           block.Extra(),
+		BaseFee:             baseFee,
+		MixHash:             block.MixDigest().Hex(),
+		TotalDifficulty:     td,
+		SchemaVersion:       models.BlockSchemaVersion,
 	}
 }
 
-// transformTransaction change transactions and events of go-ethereum to a given format
-func transformTransaction(tx *types.Transaction, sender common.Address, receipt *types.Receipt) *models.BundledTransaction {
+// transformTransaction change transactions and events of go-ethereum to a given format,
+// events will be decoded if registry has ABI of them
+//
+// Base fee of block is used to calculate effective gas price, it is nil for blocks before London fork
+func transformTransaction(tx *types.Transaction, sender common.Address, receipt *types.Receipt, baseFee *big.Int, registry *decoder.Registry) *models.BundledTransaction {
 	to := ""
 	if tx.To() != nil {
 		to = tx.To().Hex()
 	}
 
+	v, r, s := tx.RawSignatureValues()
+
 	bundleTx := &models.BundledTransaction{}
 
 	bundleTx.Transaction = &models.Transaction{
-		Hash:      tx.Hash().Hex(),
-		From:      sender.Hex(),
-		Contract:  receipt.ContractAddress.Hex(),
-		To:        to,
-		Value:     tx.Value().String(),
-		Data:      tx.Data(),
-		Gas:       tx.Gas(),
-		GasPrice:  tx.GasPrice().String(),
-		Cost:      tx.Cost().String(),
-		Nonce:     tx.Nonce(),
-		State:     receipt.Status,
-		BlockHash: receipt.BlockHash.Hex(),
+		Hash:              tx.Hash().Hex(),
+		Type:              tx.Type(),
+		ChainID:           tx.ChainId().String(),
+		From:              sender.Hex(),
+		Contract:          receipt.ContractAddress.Hex(),
+		To:                to,
+		Value:             tx.Value().String(),
+		Data:              tx.Data(),
+		Gas:               tx.Gas(),
+		GasPrice:          tx.GasPrice().String(),
+		GasTipCap:         tx.GasTipCap().String(),
+		GasFeeCap:         tx.GasFeeCap().String(),
+		AccessList:        transformAccessList(tx.AccessList()),
+		Cost:              tx.Cost().String(),
+		Nonce:             tx.Nonce(),
+		State:             receipt.Status,
+		BlockHash:         receipt.BlockHash.Hex(),
+		BlockNumber:       receipt.BlockNumber.Uint64(),
+		TransactionIndex:  receipt.TransactionIndex,
+		GasUsed:           receipt.GasUsed,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		EffectiveGasPrice: effectiveGasPrice(tx, baseFee).String(),
+		V:                 v.String(),
+		R:                 r.String(),
+		S:                 s.String(),
 	}
 
 	bundleTx.Events = make([]*models.Event, len(receipt.Logs))
@@ -64,8 +98,44 @@ func transformTransaction(tx *types.Transaction, sender common.Address, receipt
 			Data:            v.Data,
 			TransactionHash: v.TxHash.Hex(),
 			BlockHash:       v.BlockHash.Hex(),
+			BlockNumber:     v.BlockNumber,
+			Decoded:         registry.Decode(v),
 		}
 	}
 
+	bundleTx.TokenTransfers = extractTokenTransfers(receipt.Logs)
+
 	return bundleTx
 }
+
+// effectiveGasPrice function that calculates gas price that is actually paid by transaction,
+// it is min(fee cap, base fee + tip cap) for dynamic fee transaction and gas price for others
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if tx.Type() != types.DynamicFeeTxType || baseFee == nil {
+		return tx.GasPrice()
+	}
+
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return tx.GasFeeCap()
+	}
+
+	return price
+}
+
+func transformAccessList(accessList types.AccessList) []models.AccessTuple {
+	out := make([]models.AccessTuple, len(accessList))
+	for i, tuple := range accessList {
+		keys := make([]string, len(tuple.StorageKeys))
+		for j, key := range tuple.StorageKeys {
+			keys[j] = key.Hex()
+		}
+
+		out[i] = models.AccessTuple{
+			Address:     tuple.Address.Hex(),
+			StorageKeys: keys,
+		}
+	}
+
+	return out
+}
@@ -2,33 +2,45 @@ package app
 
 import (
 	"context"
+	"database/sql"
+	"go-evm-indexer/app/rpcpool"
 	"go-evm-indexer/config"
 	"go-evm-indexer/entity"
 	"log"
 	"time"
 
-	"github.com/ethereum/go-ethereum/ethclient"
+	_ "github.com/lib/pq"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// newBockChainNodeConnection function that connect to blockchain node, either using RPC and Websocket connection
+// newBockChainNodeConnection function that connect to blockchain nodes, either using RPC and Websocket connection,
+// every url of RPC and Websocket is an endpoint of pool
 func newBockChainNodeConnection() *entity.BlockChainNodeConnection {
 	blockChainNodeConn := &entity.BlockChainNodeConnection{}
 
-	if config.Get().WebsocketURL != "" {
-		websocketClient, err := ethclient.Dial(config.Get().WebsocketURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if urls := config.Get().WebsocketEndpoints(); len(urls) > 0 {
+		websocketPool, err := rpcpool.Dial(ctx, urls)
 		if err != nil {
 			log.Fatalf("❌ failed to connect websocket client : %s\n", err.Error())
 		}
-		blockChainNodeConn.Websocket = websocketClient
+		blockChainNodeConn.Websocket = websocketPool
 	}
 
-	rpcClient, err := ethclient.Dial(config.Get().RPCURL)
+	rpcPool, err := rpcpool.Dial(ctx, config.Get().RPCEndpoints())
 	if err != nil {
 		log.Fatalf("❌ failed to connect rpc client : %s\n", err.Error())
 	}
-	blockChainNodeConn.RPC = rpcClient
+	blockChainNodeConn.RPC = rpcPool
+
+	chainID, err := blockChainNodeConn.RPC.ChainID(ctx)
+	if err != nil {
+		log.Fatalf("❌ failed to get chain id : %s\n", err.Error())
+	}
+	blockChainNodeConn.ChainID = chainID
 
 	return blockChainNodeConn
 }
@@ -48,3 +60,20 @@ func newMongoClient() *mongo.Client {
 
 	return client
 }
+
+// newPostgresClient function that connect to postgres
+func newPostgresClient() *sql.DB {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	db, err := sql.Open("postgres", config.Get().PostgresURI)
+	if err != nil {
+		log.Fatalf("❌ failed to open postgres client : %s\n", err.Error())
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		log.Fatalf("❌ failed to connect postgres client : %s\n", err.Error())
+	}
+
+	return db
+}
@@ -1,6 +1,14 @@
 package common
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
 
 func StringifyEventTopics(hash []common.Hash) []string {
 	buf := make([]string, len(hash))
@@ -11,3 +19,85 @@ func StringifyEventTopics(hash []common.Hash) []string {
 
 	return buf
 }
+
+// ParseBig function that parses decimal string that is stored for big integer, it returns nil if value is empty or invalid
+func ParseBig(value string) *hexutil.Big {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil
+	}
+
+	return (*hexutil.Big)(n)
+}
+
+// AddressSet function that returns set of checksum addresses from comma separated addresses of watch filter
+func AddressSet(values string) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range splitList(values) {
+		if !common.IsHexAddress(value) {
+			log.Fatalf("❌ invalid address in filter : %s\n", value)
+		}
+
+		set[common.HexToAddress(value).Hex()] = true
+	}
+
+	return set
+}
+
+// EventSignatureSet function that returns set of topic hashes from comma separated event signatures of watch filter,
+// event signature can be topic hash or text signature like `Transfer(address,address,uint256)`
+func EventSignatureSet(values string) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range splitSignatures(values) {
+		if strings.HasPrefix(value, "0x") && len(value) == 2*common.HashLength+2 {
+			set[common.HexToHash(value).Hex()] = true
+			continue
+		}
+
+		set[crypto.Keccak256Hash([]byte(value)).Hex()] = true
+	}
+
+	return set
+}
+
+func splitList(values string) []string {
+	var out []string
+	for _, value := range strings.Split(values, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+
+	return out
+}
+
+// splitSignatures function that splits comma separated event signatures,
+// commas between parentheses are arguments of text signature
+func splitSignatures(values string) []string {
+	var (
+		out   []string
+		depth int
+		start int
+	)
+
+	add := func(value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+
+	for i, r := range values {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ',' && depth == 0:
+			add(values[start:i])
+			start = i + 1
+		}
+	}
+	add(values[start:])
+
+	return out
+}
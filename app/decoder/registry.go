@@ -0,0 +1,179 @@
+package decoder
+
+import (
+	"fmt"
+	"go-evm-indexer/models"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Registry holder of ABIs that used to decode event logs,
+// ABI can be registered to specific contract address or globally by event signature
+type Registry struct {
+	mutex *sync.RWMutex
+
+	contracts map[common.Address]*abi.ABI
+	// events that registered globally, it can be more than one event per signature
+	// because indexed arguments are not a part of signature (e.g. `Transfer` of ERC-20 and ERC-721)
+	events map[common.Hash][]abi.Event
+}
+
+// New function that new instance of empty registry
+func New() *Registry {
+	return &Registry{
+		mutex:     &sync.RWMutex{},
+		contracts: make(map[common.Address]*abi.ABI),
+		events:    make(map[common.Hash][]abi.Event),
+	}
+}
+
+// LoadDir function that new instance of registry with ABI files of directory
+//
+// ABI file that is named by contract address (e.g. `0x6B17...1d0F.json`) will be registered to that contract,
+// other ABI files will be registered globally
+func LoadDir(dir string) (*Registry, error) {
+	registry := New()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		contractABI, err := abi.JSON(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse abi file [ file : %s ] : %s", file, err.Error())
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if common.IsHexAddress(name) {
+			registry.Register(common.HexToAddress(name), &contractABI)
+		} else {
+			registry.RegisterGlobal(&contractABI)
+		}
+	}
+
+	log.Printf("✅ [ abi : %d ] loaded from %s\n", len(files), dir)
+
+	return registry, nil
+}
+
+// Register set ABI of contract address
+func (r *Registry) Register(address common.Address, contractABI *abi.ABI) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.contracts[address] = contractABI
+}
+
+// RegisterGlobal set all events of ABI by their signature, those can be used to decode logs of any contracts
+func (r *Registry) RegisterGlobal(contractABI *abi.ABI) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, event := range contractABI.Events {
+		if event.Anonymous {
+			continue
+		}
+
+		r.events[event.ID] = append(r.events[event.ID], event)
+	}
+}
+
+// Decode function that decode log by ABI of contract address first, if it is not found or can't decode
+// it will try to decode by ABI that registered globally.
+//
+// It returns nil if there are not any ABIs that can decode this log
+func (r *Registry) Decode(log *types.Log) *models.DecodedEvent {
+	if r == nil || len(log.Topics) == 0 {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if contractABI, ok := r.contracts[log.Address]; ok {
+		if event, err := contractABI.EventByID(log.Topics[0]); err == nil {
+			if decoded, err := decodeEvent(event, log); err == nil {
+				return decoded
+			}
+		}
+	}
+
+	for i := range r.events[log.Topics[0]] {
+		if decoded, err := decodeEvent(&r.events[log.Topics[0]][i], log); err == nil {
+			return decoded
+		}
+	}
+
+	return nil
+}
+
+// decodeEvent function that decode indexed arguments from topics and non-indexed arguments from data of log
+func decodeEvent(event *abi.Event, log *types.Log) (*models.DecodedEvent, error) {
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+
+	if len(indexed) != len(log.Topics)-1 {
+		return nil, fmt.Errorf("topic count mismatch")
+	}
+
+	values, err := event.Inputs.NonIndexed().UnpackValues(log.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &models.DecodedEvent{
+		Name:      event.RawName,
+		Signature: event.Sig,
+		Args:      make([]models.DecodedArgument, len(event.Inputs)),
+	}
+
+	var indexedPos, nonIndexedPos int
+	for i, input := range event.Inputs {
+		var value interface{}
+
+		if input.Indexed {
+			topic := map[string]interface{}{}
+			if err := abi.ParseTopicsIntoMap(topic, abi.Arguments{input}, log.Topics[indexedPos+1:indexedPos+2]); err != nil {
+				return nil, err
+			}
+			value = topic[input.Name]
+			indexedPos++
+		} else {
+			value = values[nonIndexedPos]
+			nonIndexedPos++
+		}
+
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+
+		decoded.Args[i] = models.DecodedArgument{
+			Name:    name,
+			Type:    input.Type.String(),
+			Indexed: input.Indexed,
+			Value:   normalize(value),
+		}
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,741 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	c "go-evm-indexer/app/common"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+const (
+	// maxBlockRange maximum number of blocks that can be queried at once
+	maxBlockRange = 1000
+	// maxLogs maximum number of logs that can be returned by logs query
+	maxLogs = 10000
+	// maxDepth maximum depth of query
+	maxDepth = 16
+)
+
+var errBlockNotFound = errors.New("block not found")
+
+// errPartial is returned by fields that can't be answered completely from indexed data when watch filters are set,
+// logs are answered when logs of filter are all indexed
+var errPartial = errors.New("not available, only filtered transactions and events are indexed")
+
+// Long is a 64 bit unsigned integer input, output of Long is `hexutil.Uint64`
+type Long int64
+
+// ImplementsGraphQLType returns true if Long implements the provided GraphQL type.
+func (b Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (b *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		value, err := strconv.ParseInt(input, 10, 64)
+		*b = Long(value)
+		return err
+	case int32:
+		*b = Long(input)
+	case int64:
+		*b = Long(input)
+	case float64:
+		*b = Long(input)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+
+	return nil
+}
+
+// New function that returns http handler of GraphQL that answers queries from repositories,
+// watch filters of config decide which fields can be answered completely
+func New(
+	blocksRepo repository.IBlocksRepository,
+	transactionsRepo repository.ITransactionsRepository,
+	eventsRepo repository.IEventsRepository,
+	cfg config.Config,
+) http.Handler {
+	resolver := &Resolver{
+		blocksRepo:       blocksRepo,
+		transactionsRepo: transactionsRepo,
+		eventsRepo:       eventsRepo,
+		partial:          cfg.Filtered(),
+		contracts:        c.AddressSet(cfg.WatchContracts),
+		signatures:       c.EventSignatureSet(cfg.WatchEventSignatures),
+	}
+
+	return &relay.Handler{
+		Schema: graphql.MustParseSchema(schema, resolver, graphql.MaxDepth(maxDepth)),
+	}
+}
+
+// Resolver is root resolver of query
+type Resolver struct {
+	blocksRepo       repository.IBlocksRepository
+	transactionsRepo repository.ITransactionsRepository
+	eventsRepo       repository.IEventsRepository
+	// partial is true when only filtered transactions and events are indexed, blocks are always complete but
+	// transactions and logs of block, logs of transaction and logs query would miss records that are filtered out
+	partial bool
+	// contracts and signatures are watched contracts and event signatures, only events that match them are indexed
+	// when any of them is set
+	contracts  map[string]bool
+	signatures map[string]bool
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*Block, error) {
+	var (
+		block *models.Block
+		err   error
+	)
+
+	switch {
+	case args.Hash != nil:
+		block, err = r.blocksRepo.FindBlockByHash(ctx, *args.Hash)
+	case args.Number != nil:
+		if *args.Number < 0 {
+			return nil, fmt.Errorf("invalid block number")
+		}
+		block, err = r.blocksRepo.FindBlockByNumber(ctx, uint64(*args.Number))
+	default:
+		block, err = r.blocksRepo.FindLastestBlock(ctx)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find block : %s", err.Error())
+	}
+
+	return r.newBlock(block), nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From *Long
+	To   *Long
+}) ([]*Block, error) {
+	var from, to uint64
+	if args.From != nil {
+		if *args.From < 0 {
+			return nil, fmt.Errorf("invalid block number")
+		}
+		from = uint64(*args.From)
+	}
+
+	if args.To != nil {
+		if *args.To < 0 {
+			return nil, fmt.Errorf("invalid block number")
+		}
+		to = uint64(*args.To)
+	} else {
+		latest, err := r.latestBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		to = latest
+	}
+
+	if to < from {
+		return []*Block{}, nil
+	}
+
+	if to-from >= maxBlockRange {
+		return nil, fmt.Errorf("block range is too large, maximum is %d blocks", maxBlockRange)
+	}
+
+	blocks, err := r.blocksRepo.FindBlockByRange(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find blocks : %s", err.Error())
+	}
+
+	out := make([]*Block, len(blocks))
+	for i := range blocks {
+		out[i] = r.newBlock(&blocks[i])
+	}
+
+	return out, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	tx, err := r.transactionsRepo.FindTransactionByHash(ctx, args.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transaction : %s", err.Error())
+	}
+
+	// Transaction that is not stored may be filtered out, it can't be answered as not existing
+	if tx == nil && r.partial {
+		return nil, errPartial
+	}
+
+	return r.newTransaction(tx), nil
+}
+
+// FilterCriteria input of logs query
+type FilterCriteria struct {
+	FromBlock *Long
+	ToBlock   *Long
+	Addresses *[]common.Address
+	Topics    *[][]common.Hash
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	addresses, topics := derefAddresses(args.Filter.Addresses), derefTopics(args.Filter.Topics)
+	if !r.coversLogs(addresses, topics) {
+		return nil, errPartial
+	}
+
+	var from, to *uint64
+	if args.Filter.FromBlock == nil || args.Filter.ToBlock == nil {
+		latest, err := r.latestBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		from, to = &latest, &latest
+	}
+
+	if args.Filter.FromBlock != nil {
+		if *args.Filter.FromBlock < 0 {
+			return nil, fmt.Errorf("invalid block number")
+		}
+		value := uint64(*args.Filter.FromBlock)
+		from = &value
+	}
+
+	if args.Filter.ToBlock != nil {
+		if *args.Filter.ToBlock < 0 {
+			return nil, fmt.Errorf("invalid block number")
+		}
+		value := uint64(*args.Filter.ToBlock)
+		to = &value
+	}
+
+	return r.findLogs(ctx, &repository.EventFilter{
+		FromBlock: from,
+		ToBlock:   to,
+		Addresses: addresses,
+		Topics:    topics,
+	})
+}
+
+// coversLogs function that checks all logs that match addresses and topics are indexed. Events are filtered by
+// watched contracts and event signatures, so every address and every first topic of filter must be watched,
+// all events of matched transactions are indexed only when events are not filtered that can't cover any logs
+func (r *Resolver) coversLogs(addresses []common.Address, topics [][]common.Hash) bool {
+	if !r.partial {
+		return true
+	}
+
+	if len(r.contracts) == 0 && len(r.signatures) == 0 {
+		return false
+	}
+
+	if len(r.contracts) > 0 {
+		if len(addresses) == 0 {
+			return false
+		}
+
+		for _, address := range addresses {
+			if !r.contracts[address.Hex()] {
+				return false
+			}
+		}
+	}
+
+	if len(r.signatures) > 0 {
+		if len(topics) == 0 || len(topics[0]) == 0 {
+			return false
+		}
+
+		for _, topic := range topics[0] {
+			if !r.signatures[topic.Hex()] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// findLogs function that finds events by filter, it returns error if number of events exceeds maximum
+func (r *Resolver) findLogs(ctx context.Context, filter *repository.EventFilter) ([]*Log, error) {
+	filter.Limit = maxLogs + 1
+
+	events, err := r.eventsRepo.FindEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find logs : %s", err.Error())
+	}
+
+	if len(events) > maxLogs {
+		return nil, fmt.Errorf("too many logs, maximum is %d logs", maxLogs)
+	}
+
+	out := make([]*Log, len(events))
+	for i := range events {
+		out[i] = &Log{r: r, event: &events[i]}
+	}
+
+	return out, nil
+}
+
+func (r *Resolver) latestBlockNumber(ctx context.Context) (uint64, error) {
+	block, err := r.blocksRepo.FindLastestBlock(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find latest block : %s", err.Error())
+	}
+
+	if block == nil {
+		return 0, errBlockNotFound
+	}
+
+	return block.Number, nil
+}
+
+func (r *Resolver) newBlock(block *models.Block) *Block {
+	if block == nil {
+		return nil
+	}
+
+	return &Block{r: r, block: block}
+}
+
+func (r *Resolver) newTransaction(tx *models.Transaction) *Transaction {
+	if tx == nil {
+		return nil
+	}
+
+	return &Transaction{r: r, tx: tx}
+}
+
+// Account is an account at a particular block, only address is available
+type Account struct {
+	address common.Address
+}
+
+func newAccount(address string) *Account {
+	return &Account{address: common.HexToAddress(address)}
+}
+
+func (a *Account) Address() common.Address {
+	return a.address
+}
+
+// accountArgs arguments of account field, block is accepted for compatibility and ignored
+type accountArgs struct {
+	Block *Long
+}
+
+// Block resolver of block, transactions of block are loaded once
+type Block struct {
+	r     *Resolver
+	block *models.Block
+
+	mutex sync.Mutex
+	txs   []*Transaction
+}
+
+func (b *Block) Number() hexutil.Uint64 {
+	return hexutil.Uint64(b.block.Number)
+}
+
+func (b *Block) Hash() common.Hash {
+	return common.HexToHash(b.block.Hash)
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.Number == 0 {
+		return nil, nil
+	}
+
+	parent, err := b.r.blocksRepo.FindBlockByHash(ctx, common.HexToHash(b.block.ParentHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find block : %s", err.Error())
+	}
+
+	return b.r.newBlock(parent), nil
+}
+
+func (b *Block) Nonce() (hexutil.Bytes, error) {
+	nonce, err := hexutil.DecodeUint64(b.block.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := types.EncodeNonce(nonce)
+	return encoded[:], nil
+}
+
+func (b *Block) TransactionsRoot() common.Hash {
+	return common.HexToHash(b.block.TransactionRootHash)
+}
+
+func (b *Block) TransactionCount(ctx context.Context) (*int32, error) {
+	txs, err := b.transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	count := int32(len(txs))
+	return &count, nil
+}
+
+func (b *Block) StateRoot() common.Hash {
+	return common.HexToHash(b.block.StateRootHash)
+}
+
+func (b *Block) ReceiptsRoot() common.Hash {
+	return common.HexToHash(b.block.ReceiptRootHash)
+}
+
+func (b *Block) Miner(_ accountArgs) *Account {
+	return newAccount(b.block.Miner)
+}
+
+func (b *Block) ExtraData() hexutil.Bytes {
+	return b.block.ExtraData
+}
+
+func (b *Block) GasLimit() hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasLimit)
+}
+
+func (b *Block) GasUsed() hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasUsed)
+}
+
+func (b *Block) BaseFeePerGas() *hexutil.Big {
+	return c.ParseBig(b.block.BaseFee)
+}
+
+func (b *Block) Timestamp() hexutil.Uint64 {
+	return hexutil.Uint64(b.block.Time)
+}
+
+func (b *Block) Difficulty() hexutil.Big {
+	if difficulty := c.ParseBig(b.block.Difficulty); difficulty != nil {
+		return *difficulty
+	}
+
+	return hexutil.Big{}
+}
+
+func (b *Block) OmmerHash() common.Hash {
+	return common.HexToHash(b.block.UncleHash)
+}
+
+func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
+	txs, err := b.transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txs, nil
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (*Transaction, error) {
+	txs, err := b.transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil, nil
+	}
+
+	return txs[args.Index], nil
+}
+
+// BlockFilterCriteria input of logs of block
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address
+	Topics    *[][]common.Hash
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	addresses, topics := derefAddresses(args.Filter.Addresses), derefTopics(args.Filter.Topics)
+	if !b.r.coversLogs(addresses, topics) {
+		return nil, errPartial
+	}
+
+	return b.r.findLogs(ctx, &repository.EventFilter{
+		FromBlock: &b.block.Number,
+		ToBlock:   &b.block.Number,
+		Addresses: addresses,
+		Topics:    topics,
+	})
+}
+
+// transactions function that loads transactions of block sorted by index, transactions of block can't be
+// answered under watch filters because transactions that are not matched are never indexed
+func (b *Block) transactions(ctx context.Context) ([]*Transaction, error) {
+	if b.r.partial {
+		return nil, errPartial
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.txs != nil {
+		return b.txs, nil
+	}
+
+	txs, err := b.r.transactionsRepo.FindTransactionsByBlockHash(ctx, common.HexToHash(b.block.Hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions : %s", err.Error())
+	}
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].TransactionIndex < txs[j].TransactionIndex
+	})
+
+	b.txs = make([]*Transaction, len(txs))
+	for i := range txs {
+		b.txs[i] = &Transaction{r: b.r, tx: &txs[i], block: b}
+	}
+
+	return b.txs, nil
+}
+
+// Transaction resolver of transaction
+type Transaction struct {
+	r     *Resolver
+	tx    *models.Transaction
+	block *Block
+}
+
+func (t *Transaction) Hash() common.Hash {
+	return common.HexToHash(t.tx.Hash)
+}
+
+func (t *Transaction) Nonce() hexutil.Uint64 {
+	return hexutil.Uint64(t.tx.Nonce)
+}
+
+func (t *Transaction) Index() *int32 {
+	index := int32(t.tx.TransactionIndex)
+	return &index
+}
+
+func (t *Transaction) From(_ accountArgs) *Account {
+	return newAccount(t.tx.From)
+}
+
+func (t *Transaction) To(_ accountArgs) *Account {
+	if t.tx.To == "" {
+		return nil
+	}
+
+	return newAccount(t.tx.To)
+}
+
+func (t *Transaction) Value() hexutil.Big {
+	return bigOrZero(t.tx.Value)
+}
+
+func (t *Transaction) GasPrice() hexutil.Big {
+	return bigOrZero(t.tx.GasPrice)
+}
+
+func (t *Transaction) MaxFeePerGas() *hexutil.Big {
+	if t.tx.Type != types.DynamicFeeTxType {
+		return nil
+	}
+
+	return c.ParseBig(t.tx.GasFeeCap)
+}
+
+func (t *Transaction) MaxPriorityFeePerGas() *hexutil.Big {
+	if t.tx.Type != types.DynamicFeeTxType {
+		return nil
+	}
+
+	return c.ParseBig(t.tx.GasTipCap)
+}
+
+func (t *Transaction) Gas() hexutil.Uint64 {
+	return hexutil.Uint64(t.tx.Gas)
+}
+
+func (t *Transaction) InputData() hexutil.Bytes {
+	return t.tx.Data
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	if t.block != nil {
+		return t.block, nil
+	}
+
+	block, err := t.r.blocksRepo.FindBlockByHash(ctx, common.HexToHash(t.tx.BlockHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find block : %s", err.Error())
+	}
+
+	return t.r.newBlock(block), nil
+}
+
+func (t *Transaction) Status() *hexutil.Uint64 {
+	status := hexutil.Uint64(t.tx.State)
+	return &status
+}
+
+func (t *Transaction) GasUsed() *hexutil.Uint64 {
+	gasUsed := hexutil.Uint64(t.tx.GasUsed)
+	return &gasUsed
+}
+
+func (t *Transaction) CumulativeGasUsed() *hexutil.Uint64 {
+	cumulativeGasUsed := hexutil.Uint64(t.tx.CumulativeGasUsed)
+	return &cumulativeGasUsed
+}
+
+func (t *Transaction) EffectiveGasPrice() *hexutil.Big {
+	return c.ParseBig(t.tx.EffectiveGasPrice)
+}
+
+func (t *Transaction) CreatedContract(_ accountArgs) *Account {
+	if t.tx.Contract == "" || common.HexToAddress(t.tx.Contract) == (common.Address{}) {
+		return nil
+	}
+
+	return newAccount(t.tx.Contract)
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	if t.r.partial {
+		return nil, errPartial
+	}
+
+	events, err := t.r.eventsRepo.FindEventsByBlockHash(ctx, common.HexToHash(t.tx.BlockHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find logs : %s", err.Error())
+	}
+
+	logs := []*Log{}
+	for i := range events {
+		if events[i].TransactionHash == t.tx.Hash {
+			logs = append(logs, &Log{r: t.r, event: &events[i], tx: t})
+		}
+	}
+
+	return &logs, nil
+}
+
+func (t *Transaction) Type() *int32 {
+	typ := int32(t.tx.Type)
+	return &typ
+}
+
+func (t *Transaction) AccessList() *[]*AccessTuple {
+	if t.tx.Type == types.LegacyTxType {
+		return nil
+	}
+
+	accessList := make([]*AccessTuple, len(t.tx.AccessList))
+	for i := range t.tx.AccessList {
+		accessList[i] = &AccessTuple{tuple: &t.tx.AccessList[i]}
+	}
+
+	return &accessList
+}
+
+// AccessTuple resolver of element of access list
+type AccessTuple struct {
+	tuple *models.AccessTuple
+}
+
+func (a *AccessTuple) Address() common.Address {
+	return common.HexToAddress(a.tuple.Address)
+}
+
+func (a *AccessTuple) StorageKeys() []common.Hash {
+	keys := make([]common.Hash, len(a.tuple.StorageKeys))
+	for i, key := range a.tuple.StorageKeys {
+		keys[i] = common.HexToHash(key)
+	}
+
+	return keys
+}
+
+// Log resolver of event
+type Log struct {
+	r     *Resolver
+	event *models.Event
+	tx    *Transaction
+}
+
+func (l *Log) Index() int32 {
+	return int32(l.event.Index)
+}
+
+func (l *Log) Account(_ accountArgs) *Account {
+	return newAccount(l.event.Origin)
+}
+
+func (l *Log) Topics() []common.Hash {
+	topics := make([]common.Hash, len(l.event.Topics))
+	for i, topic := range l.event.Topics {
+		topics[i] = common.HexToHash(topic)
+	}
+
+	return topics
+}
+
+func (l *Log) Data() hexutil.Bytes {
+	return l.event.Data
+}
+
+func (l *Log) Transaction(ctx context.Context) (*Transaction, error) {
+	if l.tx != nil {
+		return l.tx, nil
+	}
+
+	tx, err := l.r.transactionsRepo.FindTransactionByHash(ctx, common.HexToHash(l.event.TransactionHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transaction : %s", err.Error())
+	}
+
+	if tx == nil {
+		return nil, fmt.Errorf("transaction not found [ tx : %s ]", l.event.TransactionHash)
+	}
+
+	return l.r.newTransaction(tx), nil
+}
+
+func bigOrZero(value string) hexutil.Big {
+	if n := c.ParseBig(value); n != nil {
+		return *n
+	}
+
+	return hexutil.Big{}
+}
+
+func derefAddresses(addresses *[]common.Address) []common.Address {
+	if addresses == nil {
+		return nil
+	}
+
+	return *addresses
+}
+
+func derefTopics(topics *[][]common.Hash) [][]common.Hash {
+	if topics == nil {
+		return nil
+	}
+
+	return *topics
+}
@@ -0,0 +1,242 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository/repositorytest"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	hash0 = common.HexToHash("0xb0").Hex()
+	hash1 = common.HexToHash("0xb1").Hex()
+	hash2 = common.HexToHash("0xb2").Hex()
+	txA   = common.HexToHash("0xa").Hex()
+	txB   = common.HexToHash("0xb").Hex()
+	from  = common.HexToAddress("0x1").Hex()
+	to    = common.HexToAddress("0x2").Hex()
+	token = common.HexToAddress("0x3").Hex()
+	topic = common.HexToHash("0x4").Hex()
+)
+
+var (
+	testBlocks = []models.Block{
+		{Number: 0, Hash: hash0, Nonce: "0x0", Difficulty: "1"},
+		{Number: 1, Hash: hash1, ParentHash: hash0, Nonce: "0x0", Difficulty: "1"},
+		{Number: 2, Hash: hash2, ParentHash: hash1, Nonce: "0x0", Difficulty: "1"},
+	}
+	// Transactions are stored in reverse order to check that those are sorted by index
+	testTransactions = []models.Transaction{
+		{BlockHash: hash1, Hash: txB, TransactionIndex: 1, From: from, To: "", Contract: token, Value: "0", GasPrice: "1"},
+		{BlockHash: hash1, Hash: txA, TransactionIndex: 0, From: from, To: to, Value: "16", GasPrice: "1"},
+	}
+	testEvents = []models.Event{
+		{BlockHash: hash1, BlockNumber: 1, TransactionHash: txA, Index: 0, Origin: token, Topics: []string{topic}},
+		{BlockHash: hash1, BlockNumber: 1, TransactionHash: txB, Index: 1, Origin: to, Topics: []string{topic}},
+	}
+)
+
+// query function that runs query on handler and returns data and error messages of response
+func query(t *testing.T, cfg config.Config, q string) (string, []string) {
+	body, err := json.Marshal(map[string]string{"query": q})
+	if err != nil {
+		t.Fatalf("failed to encode query : %s", err.Error())
+	}
+
+	w := httptest.NewRecorder()
+	New(
+		&repositorytest.BlocksRepo{Blocks: testBlocks},
+		&repositorytest.TransactionsRepo{Txs: testTransactions},
+		&repositorytest.EventsRepo{Events: testEvents},
+		cfg,
+	).
+		ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response : %s", err.Error())
+	}
+
+	var messages []string
+	for _, e := range resp.Errors {
+		messages = append(messages, e.Message)
+	}
+
+	return string(resp.Data), messages
+}
+
+func equalJSON(t *testing.T, got, want string) bool {
+	var g, w interface{}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("failed to decode json : %s", err.Error())
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("failed to decode json : %s", err.Error())
+	}
+
+	return reflect.DeepEqual(g, w)
+}
+
+func TestQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		err   string
+	}{
+		{
+			name:  "block by number with transactions sorted by index",
+			query: `{ block(number: 1) { number hash parent { number } transactionCount transactions { hash index } } }`,
+			want:  `{"block":{"number":"0x1","hash":"` + hash1 + `","parent":{"number":"0x0"},"transactionCount":2,"transactions":[{"hash":"` + txA + `","index":0},{"hash":"` + txB + `","index":1}]}}`,
+		},
+		{
+			name:  "block by hash",
+			query: `{ block(hash: "` + hash2 + `") { number } }`,
+			want:  `{"block":{"number":"0x2"}}`,
+		},
+		{
+			name:  "latest block",
+			query: `{ block { number } }`,
+			want:  `{"block":{"number":"0x2"}}`,
+		},
+		{
+			name:  "missing block",
+			query: `{ block(number: 9) { number } }`,
+			want:  `{"block":null}`,
+		},
+		{
+			name:  "transaction out of bounds",
+			query: `{ block(number: 1) { transactionAt(index: 2) { hash } } }`,
+			want:  `{"block":{"transactionAt":null}}`,
+		},
+		{
+			name:  "blocks to latest block",
+			query: `{ blocks(from: 1) { number } }`,
+			want:  `{"blocks":[{"number":"0x1"},{"number":"0x2"}]}`,
+		},
+		{
+			name:  "too large block range",
+			query: `{ blocks(from: 0, to: 1000) { number } }`,
+			err:   "block range is too large",
+		},
+		{
+			name:  "negative block number",
+			query: `{ block(number: -1) { number } }`,
+			err:   "invalid block number",
+		},
+		{
+			name:  "transaction with logs and created contract",
+			query: `{ transaction(hash: "` + txB + `") { to { address } createdContract { address } block { number } logs { index account { address } } } }`,
+			want:  `{"transaction":{"to":null,"createdContract":{"address":"` + strings.ToLower(token) + `"},"block":{"number":"0x1"},"logs":[{"index":1,"account":{"address":"` + strings.ToLower(to) + `"}}]}}`,
+		},
+		{
+			name:  "logs by address",
+			query: `{ logs(filter: { fromBlock: 0, toBlock: 2, addresses: ["` + token + `"] }) { index topics transaction { hash } } }`,
+			want:  `{"logs":[{"index":0,"topics":["` + topic + `"],"transaction":{"hash":"` + txA + `"}}]}`,
+		},
+		{
+			name:  "logs of block",
+			query: `{ block(number: 1) { logs(filter: {}) { index } } }`,
+			want:  `{"block":{"logs":[{"index":0},{"index":1}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errs := query(t, config.Config{}, tt.query)
+
+			if tt.err != "" {
+				if len(errs) == 0 || !strings.Contains(errs[0], tt.err) {
+					t.Fatalf("got errors %v, want %q", errs, tt.err)
+				}
+				return
+			}
+
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors : %v", errs)
+			}
+
+			if !equalJSON(t, data, tt.want) {
+				t.Fatalf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartialIndexing(t *testing.T) {
+	// Events of watched token contract are indexed
+	watchToken := config.Config{WatchContracts: token}
+
+	tests := []struct {
+		name    string
+		query   string
+		watch   config.Config
+		partial bool
+	}{
+		{name: "block header", query: `{ block(number: 1) { number hash parent { number } } }`, watch: watchToken, partial: false},
+		{name: "blocks", query: `{ blocks(from: 0, to: 2) { number } }`, watch: watchToken, partial: false},
+		{name: "stored transaction", query: `{ transaction(hash: "` + txA + `") { hash from { address } } }`, watch: watchToken, partial: false},
+		{name: "transactions of block", query: `{ block(number: 1) { transactions { hash } } }`, watch: watchToken, partial: true},
+		{name: "transaction count of block", query: `{ block(number: 1) { transactionCount } }`, watch: watchToken, partial: true},
+		{name: "transaction at index", query: `{ block(number: 1) { transactionAt(index: 0) { hash } } }`, watch: watchToken, partial: true},
+		{name: "logs of block", query: `{ block(number: 1) { logs(filter: {}) { index } } }`, watch: watchToken, partial: true},
+		{name: "logs of block by watched address", query: `{ block(number: 1) { logs(filter: { addresses: ["` + token + `"] }) { index } } }`, watch: watchToken, partial: false},
+		{name: "logs of transaction", query: `{ transaction(hash: "` + txA + `") { logs { index } } }`, watch: watchToken, partial: true},
+		{name: "missing transaction", query: `{ transaction(hash: "` + common.HexToHash("0xc").Hex() + `") { hash } }`, watch: watchToken, partial: true},
+		{name: "logs", query: `{ logs(filter: { fromBlock: 0, toBlock: 2 }) { index } }`, watch: watchToken, partial: true},
+		{name: "logs by watched address", query: `{ logs(filter: { fromBlock: 0, toBlock: 2, addresses: ["` + token + `"] }) { index } }`, watch: watchToken, partial: false},
+		{name: "logs by not watched address", query: `{ logs(filter: { fromBlock: 0, toBlock: 2, addresses: ["` + to + `"] }) { index } }`, watch: watchToken, partial: true},
+		{
+			name:    "logs by watched address and not watched event signature",
+			query:   `{ logs(filter: { fromBlock: 0, toBlock: 2, addresses: ["` + token + `"], topics: [["` + common.HexToHash("0x5").Hex() + `"]] }) { index } }`,
+			watch:   config.Config{WatchContracts: token, WatchEventSignatures: topic},
+			partial: true,
+		},
+		{
+			name:    "logs by watched address and event signature",
+			query:   `{ logs(filter: { fromBlock: 0, toBlock: 2, addresses: ["` + token + `"], topics: [["` + topic + `"]] }) { index } }`,
+			watch:   config.Config{WatchContracts: token, WatchEventSignatures: topic},
+			partial: false,
+		},
+		{
+			name:    "logs by address when only transactions are watched",
+			query:   `{ logs(filter: { fromBlock: 0, toBlock: 2, addresses: ["` + token + `"] }) { index } }`,
+			watch:   config.Config{WatchFrom: from},
+			partial: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every query is complete when everything is indexed
+			if _, errs := query(t, config.Config{}, tt.query); len(errs) > 0 {
+				t.Fatalf("unexpected errors without filters : %v", errs)
+			}
+
+			_, errs := query(t, tt.watch, tt.query)
+			if !tt.partial {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors with filters : %v", errs)
+				}
+				return
+			}
+
+			if len(errs) == 0 || errs[0] != errPartial.Error() {
+				t.Fatalf("got errors %v, want %q", errs, errPartial.Error())
+			}
+		})
+	}
+}
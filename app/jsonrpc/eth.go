@@ -0,0 +1,470 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxLogs maximum number of logs that can be returned from indexed data by `eth_getLogs`
+const maxLogs = 10000
+
+var errInvalidParams = newError(codeInvalidParams, "invalid params")
+
+// Handlers return false when call can't be answered from indexed data, the call is proxied in that case
+
+func (s *Server) getBlockByNumber(ctx context.Context, params json.RawMessage) (interface{}, bool, error) {
+	var (
+		number rpc.BlockNumber
+		fullTx bool
+	)
+	if err := parseParams(params, 2, &number, &fullTx); err != nil {
+		return nil, false, err
+	}
+
+	// Latest and pending blocks are not indexed yet because of confirmations
+	if s.partial || number < 0 {
+		return nil, false, nil
+	}
+
+	block, err := s.blocksRepo.FindBlockByNumber(ctx, uint64(number))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find block : %s", err.Error())
+	}
+
+	return s.block(ctx, block, fullTx)
+}
+
+func (s *Server) getBlockByHash(ctx context.Context, params json.RawMessage) (interface{}, bool, error) {
+	var (
+		hash   common.Hash
+		fullTx bool
+	)
+	if err := parseParams(params, 2, &hash, &fullTx); err != nil {
+		return nil, false, err
+	}
+
+	if s.partial {
+		return nil, false, nil
+	}
+
+	block, err := s.blocksRepo.FindBlockByHash(ctx, hash)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find block : %s", err.Error())
+	}
+
+	return s.block(ctx, block, fullTx)
+}
+
+// block function that reconstructs block with its transactions and events, block that has uncles
+// or was stored by older schema version is not answered because some fields are not indexed
+func (s *Server) block(ctx context.Context, block *models.Block, fullTx bool) (interface{}, bool, error) {
+	if block == nil || block.SchemaVersion < models.BlockSchemaVersion || common.HexToHash(block.UncleHash) != types.EmptyUncleHash {
+		return nil, false, nil
+	}
+
+	txs, err := s.transactions(ctx, common.HexToHash(block.Hash))
+	if err != nil {
+		return nil, false, err
+	}
+
+	events, err := s.eventsRepo.FindEventsByBlockHash(ctx, common.HexToHash(block.Hash))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find events : %s", err.Error())
+	}
+
+	out, err := toRPCBlock(block, txs, events, fullTx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reconstruct block : %s", err.Error())
+	}
+
+	return out, true, nil
+}
+
+func (s *Server) getTransactionByHash(ctx context.Context, params json.RawMessage) (interface{}, bool, error) {
+	var hash common.Hash
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, false, err
+	}
+
+	// Stored transaction is complete even though filters are configured
+	tx, err := s.transactionsRepo.FindTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find transaction : %s", err.Error())
+	}
+
+	// Signature values are empty for transactions that were stored by older schema version
+	if tx == nil || tx.V == "" {
+		return nil, false, nil
+	}
+
+	return toRPCTransaction(tx), true, nil
+}
+
+func (s *Server) getTransactionReceipt(ctx context.Context, params json.RawMessage) (interface{}, bool, error) {
+	var hash common.Hash
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, false, err
+	}
+
+	if s.partial {
+		return nil, false, nil
+	}
+
+	tx, err := s.transactionsRepo.FindTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find transaction : %s", err.Error())
+	}
+
+	// Transactions that were stored by older schema version don't have all fields of receipt
+	if tx == nil || tx.V == "" {
+		return nil, false, nil
+	}
+
+	events, err := s.eventsRepo.FindEventsByBlockHash(ctx, common.HexToHash(tx.BlockHash))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find events : %s", err.Error())
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Index < events[j].Index
+	})
+
+	logs := []*rpcLog{}
+	for i := range events {
+		if events[i].TransactionHash == tx.Hash {
+			logs = append(logs, toRPCLog(&events[i], tx.TransactionIndex))
+		}
+	}
+
+	return toRPCReceipt(tx, logs), true, nil
+}
+
+// filterCriteria parameter of `eth_getLogs`, address and topics are kept as they are
+// to forward the rest of range that is not indexed yet
+type filterCriteria struct {
+	BlockHash *common.Hash     `json:"blockHash"`
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Address   json.RawMessage  `json:"address"`
+	Topics    json.RawMessage  `json:"topics"`
+}
+
+// getLogs function that answers logs of indexed range that starts from `fromBlock`,
+// logs of the rest of range are fetched from proxy and appended
+func (s *Server) getLogs(ctx context.Context, params json.RawMessage) (interface{}, bool, error) {
+	var criteria filterCriteria
+	if err := parseParams(params, 1, &criteria); err != nil {
+		return nil, false, err
+	}
+
+	addresses, err := parseAddresses(criteria.Address)
+	if err != nil {
+		return nil, false, err
+	}
+
+	topics, err := parseTopics(criteria.Topics)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if s.partial {
+		return nil, false, nil
+	}
+
+	from, to, ok, err := s.logsRange(ctx, &criteria)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	if from > to {
+		return []*rpcLog{}, true, nil
+	}
+
+	ranges, err := s.syncStateRepo.FindSyncRangesByRange(ctx, from, to)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find sync ranges : %s", err.Error())
+	}
+
+	end, ok := indexedUntil(ranges, from, to)
+	if !ok {
+		return nil, false, nil
+	}
+
+	events, err := s.eventsRepo.FindEvents(ctx, &repository.EventFilter{
+		FromBlock: &from,
+		ToBlock:   &end,
+		Addresses: addresses,
+		Topics:    topics,
+		Limit:     maxLogs + 1,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find events : %s", err.Error())
+	}
+
+	if len(events) > maxLogs {
+		return nil, false, newError(codeLimitExceeded, fmt.Sprintf("query returned more than %d results", maxLogs))
+	}
+
+	logs, err := s.logs(ctx, events)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if end == to {
+		return logs, true, nil
+	}
+
+	rest, err := s.forward(ctx, "eth_getLogs", restOfRange(&criteria, end+1, to))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var restLogs []json.RawMessage
+	if err := json.Unmarshal(rest, &restLogs); err != nil {
+		return nil, false, fmt.Errorf("failed to decode logs of proxy : %s", err.Error())
+	}
+
+	out := make([]interface{}, 0, len(logs)+len(restLogs))
+	for _, l := range logs {
+		out = append(out, l)
+	}
+	for _, l := range restLogs {
+		out = append(out, l)
+	}
+
+	return out, true, nil
+}
+
+// logsRange function that resolves block range of filter criteria, `latest` is resolved by block number of proxy
+// and the call is proxied when `pending` or block hash that is not indexed is requested
+func (s *Server) logsRange(ctx context.Context, criteria *filterCriteria) (uint64, uint64, bool, error) {
+	if criteria.BlockHash != nil {
+		if criteria.FromBlock != nil || criteria.ToBlock != nil {
+			return 0, 0, false, newError(codeInvalidParams, "cannot specify both blockHash and fromBlock/toBlock")
+		}
+
+		block, err := s.blocksRepo.FindBlockByHash(ctx, *criteria.BlockHash)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("failed to find block : %s", err.Error())
+		}
+
+		if block == nil {
+			return 0, 0, false, nil
+		}
+
+		return block.Number, block.Number, true, nil
+	}
+
+	var (
+		head    *uint64
+		numbers = make([]uint64, 2)
+	)
+
+	for i, number := range []*rpc.BlockNumber{criteria.FromBlock, criteria.ToBlock} {
+		switch {
+		case number != nil && *number == rpc.PendingBlockNumber:
+			return 0, 0, false, nil
+		case number != nil && *number >= 0:
+			numbers[i] = uint64(*number)
+			continue
+		}
+
+		if head == nil {
+			var out hexutil.Uint64
+			if err := s.proxy.CallContext(ctx, &out, "eth_blockNumber"); err != nil {
+				return 0, 0, false, fmt.Errorf("failed to get block number of proxy : %s", err.Error())
+			}
+
+			head = (*uint64)(&out)
+		}
+
+		numbers[i] = *head
+	}
+
+	return numbers[0], numbers[1], true, nil
+}
+
+// logs function that converts events to logs, transaction index is loaded from transactions of each block
+func (s *Server) logs(ctx context.Context, events []models.Event) ([]*rpcLog, error) {
+	indexes := make(map[string]uint)
+	loaded := make(map[string]bool)
+
+	out := make([]*rpcLog, len(events))
+	for i := range events {
+		if !loaded[events[i].BlockHash] {
+			txs, err := s.transactions(ctx, common.HexToHash(events[i].BlockHash))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, tx := range txs {
+				indexes[tx.Hash] = tx.TransactionIndex
+			}
+			loaded[events[i].BlockHash] = true
+		}
+
+		out[i] = toRPCLog(&events[i], indexes[events[i].TransactionHash])
+	}
+
+	return out, nil
+}
+
+// transactions function that finds transactions of block sorted by index
+func (s *Server) transactions(ctx context.Context, blockHash common.Hash) ([]models.Transaction, error) {
+	txs, err := s.transactionsRepo.FindTransactionsByBlockHash(ctx, blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions : %s", err.Error())
+	}
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].TransactionIndex < txs[j].TransactionIndex
+	})
+
+	return txs, nil
+}
+
+// indexedUntil function that returns the last block number of contiguous indexed range that starts from `from`,
+// ranges must be sorted by from ascending. It returns false if `from` is not indexed
+func indexedUntil(ranges []models.SyncRange, from, to uint64) (uint64, bool) {
+	var (
+		next  = from
+		found = false
+	)
+
+	for _, r := range ranges {
+		if r.From > next {
+			break
+		}
+
+		if r.To >= next {
+			next = r.To + 1
+			found = true
+		}
+
+		if next > to {
+			return to, true
+		}
+	}
+
+	return next - 1, found
+}
+
+// restOfRange function that returns params of `eth_getLogs` for range that is not indexed
+func restOfRange(criteria *filterCriteria, from, to uint64) json.RawMessage {
+	rest := map[string]interface{}{
+		"fromBlock": hexutil.Uint64(from),
+		"toBlock":   hexutil.Uint64(to),
+	}
+
+	if len(criteria.Address) > 0 {
+		rest["address"] = criteria.Address
+	}
+
+	if len(criteria.Topics) > 0 {
+		rest["topics"] = criteria.Topics
+	}
+
+	out, _ := json.Marshal([]interface{}{rest})
+	return out
+}
+
+// parseParams function that decodes positional params, trailing optional params can be omitted
+func parseParams(params json.RawMessage, required int, values ...interface{}) error {
+	var args []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return errInvalidParams
+		}
+	}
+
+	if len(args) < required {
+		return newError(codeInvalidParams, fmt.Sprintf("missing value for required argument %d", len(args)))
+	}
+
+	if len(args) > len(values) {
+		return newError(codeInvalidParams, fmt.Sprintf("too many arguments, want at most %d", len(values)))
+	}
+
+	for i, arg := range args {
+		if err := json.Unmarshal(arg, values[i]); err != nil {
+			return newError(codeInvalidParams, fmt.Sprintf("invalid argument %d: %s", i, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// parseAddresses function that decodes address of filter criteria, it can be a single address or list of addresses
+func parseAddresses(raw json.RawMessage) ([]common.Address, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var address common.Address
+	if err := json.Unmarshal(raw, &address); err == nil {
+		return []common.Address{address}, nil
+	}
+
+	var addresses []common.Address
+	if err := json.Unmarshal(raw, &addresses); err != nil {
+		return nil, newError(codeInvalidParams, "invalid addresses in query")
+	}
+
+	return addresses, nil
+}
+
+// parseTopics function that decodes topics of filter criteria, each position can be null, a single topic or list of topics.
+// Position that contains null matches any topic
+func parseTopics(raw json.RawMessage) ([][]common.Hash, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var positions []json.RawMessage
+	if err := json.Unmarshal(raw, &positions); err != nil {
+		return nil, newError(codeInvalidParams, "invalid topics in query")
+	}
+
+	out := make([][]common.Hash, len(positions))
+	for i, position := range positions {
+		if string(position) == "null" {
+			continue
+		}
+
+		if strings.HasPrefix(string(position), "\"") {
+			var topic common.Hash
+			if err := json.Unmarshal(position, &topic); err != nil {
+				return nil, newError(codeInvalidParams, "invalid topic in query")
+			}
+
+			out[i] = []common.Hash{topic}
+			continue
+		}
+
+		var topics []*common.Hash
+		if err := json.Unmarshal(position, &topics); err != nil {
+			return nil, newError(codeInvalidParams, "invalid topic in query")
+		}
+
+		for _, topic := range topics {
+			if topic == nil {
+				out[i] = nil
+				break
+			}
+
+			out[i] = append(out[i], *topic)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,339 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-evm-indexer/repository"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// maxRequestSize maximum size of request body
+	maxRequestSize = 5 * 1024 * 1024
+	// maxBatchSize maximum number of calls in a batch request
+	maxBatchSize = 100
+)
+
+// Error codes of json-rpc 2.0, -32005 is the same as limit exceeded error of most providers
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeLimitExceeded  = -32005
+)
+
+// forwardedMethods read-only methods that are forwarded to proxy, other methods like sending transactions
+// or methods of admin, debug and personal namespaces of node are never exposed
+var forwardedMethods = map[string]bool{
+	"eth_blockNumber":                         true,
+	"eth_call":                                true,
+	"eth_chainId":                             true,
+	"eth_estimateGas":                         true,
+	"eth_feeHistory":                          true,
+	"eth_gasPrice":                            true,
+	"eth_getBalance":                          true,
+	"eth_getBlockByHash":                      true,
+	"eth_getBlockByNumber":                    true,
+	"eth_getBlockReceipts":                    true,
+	"eth_getBlockTransactionCountByHash":      true,
+	"eth_getBlockTransactionCountByNumber":    true,
+	"eth_getCode":                             true,
+	"eth_getLogs":                             true,
+	"eth_getProof":                            true,
+	"eth_getStorageAt":                        true,
+	"eth_getTransactionByBlockHashAndIndex":   true,
+	"eth_getTransactionByBlockNumberAndIndex": true,
+	"eth_getTransactionByHash":                true,
+	"eth_getTransactionCount":                 true,
+	"eth_getTransactionReceipt":               true,
+	"eth_getUncleByBlockHashAndIndex":         true,
+	"eth_getUncleByBlockNumberAndIndex":       true,
+	"eth_getUncleCountByBlockHash":            true,
+	"eth_getUncleCountByBlockNumber":          true,
+	"eth_maxPriorityFeePerGas":                true,
+	"eth_protocolVersion":                     true,
+	"eth_syncing":                             true,
+	"net_listening":                           true,
+	"net_peerCount":                           true,
+	"net_version":                             true,
+	"web3_clientVersion":                      true,
+	"web3_sha3":                               true,
+}
+
+// Proxy is upstream that calls are forwarded to when they can't be answered from indexed data
+type Proxy interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// Server json-rpc server that answers `eth_*` methods from indexed data like a blockchain node
+type Server struct {
+	blocksRepo       repository.IBlocksRepository
+	transactionsRepo repository.ITransactionsRepository
+	eventsRepo       repository.IEventsRepository
+	syncStateRepo    repository.ISyncStateRepository
+
+	proxy Proxy
+	// partial is true when only filtered transactions and events are indexed,
+	// blocks, receipts and logs are always proxied in that case
+	partial bool
+
+	server *http.Server
+}
+
+func New(
+	addr string,
+
+	blocksRepo repository.IBlocksRepository,
+	transactionsRepo repository.ITransactionsRepository,
+	eventsRepo repository.IEventsRepository,
+	syncStateRepo repository.ISyncStateRepository,
+
+	proxy Proxy,
+	partial bool,
+) *Server {
+	s := &Server{
+		blocksRepo:       blocksRepo,
+		transactionsRepo: transactionsRepo,
+		eventsRepo:       eventsRepo,
+		syncStateRepo:    syncStateRepo,
+
+		proxy:   proxy,
+		partial: partial,
+	}
+
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      s,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	return s
+}
+
+// Start function that serves json-rpc server in background
+func (s *Server) Start() {
+	log.Printf("starting json-rpc server on %s\n", s.server.Addr)
+
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ json-rpc server stopped : %s\n", err.Error())
+		}
+	}()
+}
+
+// Shutdown function that stops json-rpc server after all active requests are completed
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error error object of json-rpc response
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code int, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// ServeHTTP function that handles single and batch json-rpc requests over http
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	if len(body) > maxRequestSize {
+		http.Error(w, "request is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body = bytes.TrimLeft(body, " \t\r\n")
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []json.RawMessage
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			writeResponse(w, errorResponse(nil, newError(codeParseError, "parse error")))
+			return
+		}
+
+		if len(reqs) == 0 {
+			writeResponse(w, errorResponse(nil, newError(codeInvalidRequest, "empty batch")))
+			return
+		}
+
+		if len(reqs) > maxBatchSize {
+			writeResponse(w, errorResponse(nil, newError(codeLimitExceeded, "batch is too large")))
+			return
+		}
+
+		out := make([]*response, len(reqs))
+		for i, req := range reqs {
+			out[i] = s.handle(r.Context(), req)
+		}
+
+		writeResponse(w, out)
+		return
+	}
+
+	writeResponse(w, s.handle(r.Context(), body))
+}
+
+// handle function that answers a single call, unknown calls are forwarded to proxy
+func (s *Server) handle(ctx context.Context, body json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(nil, newError(codeParseError, "parse error"))
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, newError(codeInvalidRequest, "invalid request"))
+	}
+
+	result, err := s.call(ctx, req.Method, req.Params)
+	if err != nil {
+		return errorResponse(req.ID, toError(err))
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("❌ failed to marshal json-rpc result [ method : %s ] : %s\n", req.Method, err.Error())
+		return errorResponse(req.ID, newError(codeInternalError, "internal error"))
+	}
+
+	return &response{JSONRPC: "2.0", ID: idOrNull(req.ID), Result: out}
+}
+
+// call function that calls method from indexed data, read-only method is proxied when it is not supported
+// or data is not indexed yet
+func (s *Server) call(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	if !forwardedMethods[method] {
+		return nil, newError(codeMethodNotFound, fmt.Sprintf("the method %s does not exist/is not available", method))
+	}
+
+	var (
+		result interface{}
+		ok     bool
+		err    error
+	)
+
+	switch method {
+	case "eth_getBlockByNumber":
+		result, ok, err = s.getBlockByNumber(ctx, params)
+	case "eth_getBlockByHash":
+		result, ok, err = s.getBlockByHash(ctx, params)
+	case "eth_getTransactionByHash":
+		result, ok, err = s.getTransactionByHash(ctx, params)
+	case "eth_getTransactionReceipt":
+		result, ok, err = s.getTransactionReceipt(ctx, params)
+	case "eth_getLogs":
+		result, ok, err = s.getLogs(ctx, params)
+	}
+
+	if err != nil || ok {
+		return result, err
+	}
+
+	return s.forward(ctx, method, params)
+}
+
+// forward function that calls method on proxy, error response of proxy is returned as it is
+func (s *Server) forward(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	var args []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, errInvalidParams
+		}
+	}
+
+	values := make([]interface{}, len(args))
+	for i := range args {
+		values[i] = args[i]
+	}
+
+	var result json.RawMessage
+	if err := s.proxy.CallContext(ctx, &result, method, values...); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			out := &Error{Code: rpcErr.ErrorCode(), Message: rpcErr.Error()}
+
+			var dataErr rpc.DataError
+			if errors.As(err, &dataErr) {
+				out.Data = dataErr.ErrorData()
+			}
+
+			return nil, out
+		}
+
+		log.Printf("❌ failed to proxy json-rpc call [ method : %s ] : %s\n", method, err.Error())
+		return nil, newError(codeInternalError, "upstream is unavailable")
+	}
+
+	return result, nil
+}
+
+func toError(err error) *Error {
+	var out *Error
+	if errors.As(err, &out) {
+		return out
+	}
+
+	log.Printf("❌ failed to handle json-rpc call : %s\n", err.Error())
+	return newError(codeInternalError, "internal error")
+}
+
+func errorResponse(id json.RawMessage, err *Error) *response {
+	return &response{JSONRPC: "2.0", ID: idOrNull(id), Error: err}
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+
+	return id
+}
+
+func writeResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("❌ failed to write json-rpc response : %s\n", err.Error())
+	}
+}
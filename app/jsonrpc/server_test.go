@@ -0,0 +1,220 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"go-evm-indexer/repository/repositorytest"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chainFixture indexed data and responses of node for the same chain, it is generated by
+// running an in-process node of go-ethereum and indexing its blocks with transformation of indexer
+type chainFixture struct {
+	Blocks       []models.Block       `json:"blocks"`
+	Transactions []models.Transaction `json:"transactions"`
+	Events       []models.Event       `json:"events"`
+	Calls        []struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+	} `json:"calls"`
+}
+
+func loadFixture(t *testing.T) *chainFixture {
+	data, err := os.ReadFile("testdata/chain.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture : %s", err.Error())
+	}
+
+	var fixture chainFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatalf("failed to decode fixture : %s", err.Error())
+	}
+
+	// Schema version is not serialized
+	for i := range fixture.Blocks {
+		fixture.Blocks[i].SchemaVersion = models.BlockSchemaVersion
+	}
+
+	return &fixture
+}
+
+type fakeSyncStateRepo struct {
+	repository.ISyncStateRepository
+	ranges []models.SyncRange
+}
+
+func (r *fakeSyncStateRepo) FindSyncRangesByRange(_ context.Context, from, to uint64) ([]models.SyncRange, error) {
+	return r.ranges, nil
+}
+
+// fakeProxy proxy that records forwarded methods and answers them with result
+type fakeProxy struct {
+	result  string
+	methods []string
+}
+
+func (p *fakeProxy) CallContext(_ context.Context, result interface{}, method string, _ ...interface{}) error {
+	p.methods = append(p.methods, method)
+	return json.Unmarshal([]byte(p.result), result)
+}
+
+func newTestServer(fixture *chainFixture, proxy *fakeProxy) *Server {
+	return New(
+		"",
+		&repositorytest.BlocksRepo{Blocks: fixture.Blocks},
+		&repositorytest.TransactionsRepo{Txs: reversed(fixture.Transactions)},
+		&repositorytest.EventsRepo{Events: fixture.Events},
+		&fakeSyncStateRepo{ranges: []models.SyncRange{{From: 0, To: fixture.Blocks[len(fixture.Blocks)-1].Number}}},
+		proxy,
+		false,
+	)
+}
+
+// reversed function that returns transactions in reverse order to check that transactions are sorted by index
+func reversed(txs []models.Transaction) []models.Transaction {
+	out := make([]models.Transaction, len(txs))
+	for i := range txs {
+		out[len(txs)-1-i] = txs[i]
+	}
+
+	return out
+}
+
+func callServer(t *testing.T, s *Server, method string, params json.RawMessage) *response {
+	body, err := json.Marshal(&request{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: method, Params: params})
+	if err != nil {
+		t.Fatalf("failed to encode request : %s", err.Error())
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response : %s", err.Error())
+	}
+
+	return &resp
+}
+
+func decode(t *testing.T, data json.RawMessage) interface{} {
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("failed to decode json : %s", err.Error())
+	}
+
+	return out
+}
+
+func TestGoldenResponses(t *testing.T) {
+	fixture := loadFixture(t)
+
+	for _, call := range fixture.Calls {
+		t.Run(call.Method+string(call.Params), func(t *testing.T) {
+			proxy := &fakeProxy{result: "null"}
+			resp := callServer(t, newTestServer(fixture, proxy), call.Method, call.Params)
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error : %s", resp.Error.Message)
+			}
+
+			if len(proxy.methods) > 0 {
+				t.Fatalf("call was proxied : %v", proxy.methods)
+			}
+
+			want, got := decode(t, call.Result), decode(t, resp.Result)
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("response doesn't match node\nwant : %s\ngot  : %s", call.Result, resp.Result)
+			}
+		})
+	}
+}
+
+func TestOutdatedDataIsProxied(t *testing.T) {
+	fixture := loadFixture(t)
+
+	for i := range fixture.Blocks {
+		fixture.Blocks[i].SchemaVersion = models.BlockSchemaVersion - 1
+	}
+
+	for i := range fixture.Transactions {
+		fixture.Transactions[i].V = ""
+	}
+
+	tests := []struct {
+		method string
+		params string
+	}{
+		{"eth_getBlockByNumber", `["0x1", true]`},
+		{"eth_getBlockByHash", `["` + fixture.Blocks[0].Hash + `", false]`},
+		{"eth_getTransactionByHash", `["` + fixture.Transactions[0].Hash + `"]`},
+		{"eth_getTransactionReceipt", `["` + fixture.Transactions[0].Hash + `"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			proxy := &fakeProxy{result: `{"proxied":true}`}
+			resp := callServer(t, newTestServer(fixture, proxy), tt.method, json.RawMessage(tt.params))
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error : %s", resp.Error.Message)
+			}
+
+			if len(proxy.methods) != 1 || proxy.methods[0] != tt.method {
+				t.Fatalf("call wasn't proxied : %v", proxy.methods)
+			}
+		})
+	}
+}
+
+func TestForwardedMethods(t *testing.T) {
+	fixture := loadFixture(t)
+
+	tests := []struct {
+		method    string
+		forwarded bool
+	}{
+		{"eth_chainId", true},
+		{"eth_call", true},
+		{"net_version", true},
+		{"web3_clientVersion", true},
+		{"eth_sendRawTransaction", false},
+		{"eth_sign", false},
+		{"eth_accounts", false},
+		{"admin_peers", false},
+		{"debug_traceTransaction", false},
+		{"personal_unlockAccount", false},
+		{"miner_start", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			proxy := &fakeProxy{result: `"0x1"`}
+			resp := callServer(t, newTestServer(fixture, proxy), tt.method, json.RawMessage("[]"))
+
+			if tt.forwarded {
+				if resp.Error != nil || len(proxy.methods) != 1 {
+					t.Fatalf("call wasn't forwarded : %v", resp.Error)
+				}
+				return
+			}
+
+			if len(proxy.methods) > 0 {
+				t.Fatalf("call was forwarded : %v", proxy.methods)
+			}
+
+			if resp.Error == nil || resp.Error.Code != codeMethodNotFound || !strings.Contains(resp.Error.Message, tt.method) {
+				t.Fatalf("unexpected response : %+v", resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package jsonrpc
+
+import (
+	"go-evm-indexer/models"
+
+	c "go-evm-indexer/app/common"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// rpcBlock block in the same shape as `eth_getBlockBy*` of node,
+// `totalDifficulty` is omitted when node didn't return it while indexing
+type rpcBlock struct {
+	Number           hexutil.Uint64   `json:"number"`
+	Hash             common.Hash      `json:"hash"`
+	ParentHash       common.Hash      `json:"parentHash"`
+	Nonce            types.BlockNonce `json:"nonce"`
+	MixHash          common.Hash      `json:"mixHash"`
+	Sha3Uncles       common.Hash      `json:"sha3Uncles"`
+	LogsBloom        types.Bloom      `json:"logsBloom"`
+	TransactionsRoot common.Hash      `json:"transactionsRoot"`
+	StateRoot        common.Hash      `json:"stateRoot"`
+	ReceiptsRoot     common.Hash      `json:"receiptsRoot"`
+	Miner            common.Address   `json:"miner"`
+	Difficulty       *hexutil.Big     `json:"difficulty"`
+	TotalDifficulty  *hexutil.Big     `json:"totalDifficulty,omitempty"`
+	ExtraData        hexutil.Bytes    `json:"extraData"`
+	Size             hexutil.Uint64   `json:"size"`
+	GasLimit         hexutil.Uint64   `json:"gasLimit"`
+	GasUsed          hexutil.Uint64   `json:"gasUsed"`
+	Timestamp        hexutil.Uint64   `json:"timestamp"`
+	BaseFeePerGas    *hexutil.Big     `json:"baseFeePerGas,omitempty"`
+	// Transactions are hashes or full transactions
+	Transactions []interface{} `json:"transactions"`
+	Uncles       []common.Hash `json:"uncles"`
+}
+
+// rpcTransaction transaction in the same shape as `eth_getTransactionByHash` of node
+type rpcTransaction struct {
+	BlockHash        common.Hash       `json:"blockHash"`
+	BlockNumber      hexutil.Uint64    `json:"blockNumber"`
+	From             common.Address    `json:"from"`
+	Gas              hexutil.Uint64    `json:"gas"`
+	GasPrice         *hexutil.Big      `json:"gasPrice"`
+	GasFeeCap        *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	GasTipCap        *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Hash             common.Hash       `json:"hash"`
+	Input            hexutil.Bytes     `json:"input"`
+	Nonce            hexutil.Uint64    `json:"nonce"`
+	To               *common.Address   `json:"to"`
+	TransactionIndex hexutil.Uint64    `json:"transactionIndex"`
+	Value            *hexutil.Big      `json:"value"`
+	Type             hexutil.Uint64    `json:"type"`
+	AccessList       *types.AccessList `json:"accessList,omitempty"`
+	ChainID          *hexutil.Big      `json:"chainId,omitempty"`
+	V                *hexutil.Big      `json:"v"`
+	R                *hexutil.Big      `json:"r"`
+	S                *hexutil.Big      `json:"s"`
+}
+
+// rpcReceipt receipt in the same shape as `eth_getTransactionReceipt` of node
+type rpcReceipt struct {
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	Logs              []*rpcLog       `json:"logs"`
+	LogsBloom         types.Bloom     `json:"logsBloom"`
+	Type              hexutil.Uint64  `json:"type"`
+	Status            hexutil.Uint64  `json:"status"`
+}
+
+// rpcLog log in the same shape as `eth_getLogs` of node
+type rpcLog struct {
+	Address          common.Address `json:"address"`
+	Topics           []common.Hash  `json:"topics"`
+	Data             hexutil.Bytes  `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	LogIndex         hexutil.Uint64 `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+}
+
+// toRPCBlock function that reconstructs block of node from indexed block, transactions and events of block,
+// transactions must be sorted by index
+func toRPCBlock(block *models.Block, txs []models.Transaction, events []models.Event, fullTx bool) (*rpcBlock, error) {
+	nonce, err := hexutil.DecodeUint64(block.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &rpcBlock{
+		Number:           hexutil.Uint64(block.Number),
+		Hash:             common.HexToHash(block.Hash),
+		ParentHash:       common.HexToHash(block.ParentHash),
+		Nonce:            types.EncodeNonce(nonce),
+		MixHash:          common.HexToHash(block.MixHash),
+		Sha3Uncles:       common.HexToHash(block.UncleHash),
+		LogsBloom:        bloom(events),
+		TransactionsRoot: common.HexToHash(block.TransactionRootHash),
+		StateRoot:        common.HexToHash(block.StateRootHash),
+		ReceiptsRoot:     common.HexToHash(block.ReceiptRootHash),
+		Miner:            common.HexToAddress(block.Miner),
+		Difficulty:       bigOrZero(block.Difficulty),
+		TotalDifficulty:  c.ParseBig(block.TotalDifficulty),
+		ExtraData:        block.ExtraData,
+		Size:             hexutil.Uint64(block.Size),
+		GasLimit:         hexutil.Uint64(block.GasLimit),
+		GasUsed:          hexutil.Uint64(block.GasUsed),
+		Timestamp:        hexutil.Uint64(block.Time),
+		BaseFeePerGas:    c.ParseBig(block.BaseFee),
+		Transactions:     make([]interface{}, len(txs)),
+		Uncles:           []common.Hash{},
+	}
+
+	for i := range txs {
+		if fullTx {
+			out.Transactions[i] = toRPCTransaction(&txs[i])
+		} else {
+			out.Transactions[i] = common.HexToHash(txs[i].Hash)
+		}
+	}
+
+	return out, nil
+}
+
+// toRPCTransaction function that reconstructs transaction of node from indexed transaction,
+// gas price of dynamic fee transaction is effective gas price like the node returns for mined transaction
+func toRPCTransaction(tx *models.Transaction) *rpcTransaction {
+	out := &rpcTransaction{
+		BlockHash:        common.HexToHash(tx.BlockHash),
+		BlockNumber:      hexutil.Uint64(tx.BlockNumber),
+		From:             common.HexToAddress(tx.From),
+		Gas:              hexutil.Uint64(tx.Gas),
+		GasPrice:         bigOrZero(tx.GasPrice),
+		Hash:             common.HexToHash(tx.Hash),
+		Input:            tx.Data,
+		Nonce:            hexutil.Uint64(tx.Nonce),
+		To:               toAddress(tx.To),
+		TransactionIndex: hexutil.Uint64(tx.TransactionIndex),
+		Value:            bigOrZero(tx.Value),
+		Type:             hexutil.Uint64(tx.Type),
+		V:                bigOrZero(tx.V),
+		R:                bigOrZero(tx.R),
+		S:                bigOrZero(tx.S),
+	}
+
+	if tx.Type == types.LegacyTxType {
+		return out
+	}
+
+	accessList := toAccessList(tx.AccessList)
+	out.AccessList = &accessList
+	out.ChainID = c.ParseBig(tx.ChainID)
+
+	if tx.Type == types.DynamicFeeTxType {
+		out.GasFeeCap = c.ParseBig(tx.GasFeeCap)
+		out.GasTipCap = c.ParseBig(tx.GasTipCap)
+
+		if price := c.ParseBig(tx.EffectiveGasPrice); price != nil {
+			out.GasPrice = price
+		}
+	}
+
+	return out
+}
+
+// toRPCReceipt function that reconstructs receipt of node from indexed transaction and its events
+func toRPCReceipt(tx *models.Transaction, logs []*rpcLog) *rpcReceipt {
+	out := &rpcReceipt{
+		BlockHash:         common.HexToHash(tx.BlockHash),
+		BlockNumber:       hexutil.Uint64(tx.BlockNumber),
+		TransactionHash:   common.HexToHash(tx.Hash),
+		TransactionIndex:  hexutil.Uint64(tx.TransactionIndex),
+		From:              common.HexToAddress(tx.From),
+		To:                toAddress(tx.To),
+		GasUsed:           hexutil.Uint64(tx.GasUsed),
+		CumulativeGasUsed: hexutil.Uint64(tx.CumulativeGasUsed),
+		EffectiveGasPrice: bigOrZero(tx.EffectiveGasPrice),
+		Logs:              logs,
+		LogsBloom:         logsBloom(logs),
+		Type:              hexutil.Uint64(tx.Type),
+		Status:            hexutil.Uint64(tx.State),
+	}
+
+	// Contract address of receipt is zero address when transaction doesn't create contract
+	if tx.To == "" {
+		out.ContractAddress = toAddress(tx.Contract)
+	}
+
+	return out
+}
+
+func toRPCLog(event *models.Event, txIndex uint) *rpcLog {
+	topics := make([]common.Hash, len(event.Topics))
+	for i, topic := range event.Topics {
+		topics[i] = common.HexToHash(topic)
+	}
+
+	return &rpcLog{
+		Address:          common.HexToAddress(event.Origin),
+		Topics:           topics,
+		Data:             event.Data,
+		BlockNumber:      hexutil.Uint64(event.BlockNumber),
+		TransactionHash:  common.HexToHash(event.TransactionHash),
+		TransactionIndex: hexutil.Uint64(txIndex),
+		BlockHash:        common.HexToHash(event.BlockHash),
+		LogIndex:         hexutil.Uint64(event.Index),
+	}
+}
+
+// bloom function that calculates logs bloom of block from all events of block
+func bloom(events []models.Event) types.Bloom {
+	logs := make([]*rpcLog, len(events))
+	for i := range events {
+		logs[i] = toRPCLog(&events[i], 0)
+	}
+
+	return logsBloom(logs)
+}
+
+func logsBloom(logs []*rpcLog) types.Bloom {
+	out := make([]*types.Log, len(logs))
+	for i, l := range logs {
+		out[i] = &types.Log{Address: l.Address, Topics: l.Topics}
+	}
+
+	return types.BytesToBloom(types.LogsBloom(out))
+}
+
+func toAccessList(accessList []models.AccessTuple) types.AccessList {
+	out := make(types.AccessList, len(accessList))
+	for i, tuple := range accessList {
+		keys := make([]common.Hash, len(tuple.StorageKeys))
+		for j, key := range tuple.StorageKeys {
+			keys[j] = common.HexToHash(key)
+		}
+
+		out[i] = types.AccessTuple{
+			Address:     common.HexToAddress(tuple.Address),
+			StorageKeys: keys,
+		}
+	}
+
+	return out
+}
+
+// toAddress function that returns nil for empty or zero address
+func toAddress(value string) *common.Address {
+	if value == "" {
+		return nil
+	}
+
+	address := common.HexToAddress(value)
+	if address == (common.Address{}) {
+		return nil
+	}
+
+	return &address
+}
+
+func bigOrZero(value string) *hexutil.Big {
+	if n := c.ParseBig(value); n != nil {
+		return n
+	}
+
+	return new(hexutil.Big)
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "evm_indexer"
+
+// Stages of block processing that can be failed
+const (
+	StageFetchBlock   = "fetch_block"
+	StageFetchReceipt = "fetch_receipt"
+	StageSender       = "sender"
+	StageDB           = "db"
+	StageReorg        = "reorg"
+	StageTrace        = "trace"
+	StageSink         = "sink"
+	StageBalance      = "balance"
+)
+
+var (
+	ChainHead = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_head_block",
+		Help:      "Latest block number received from blockchain node.",
+	})
+	LatestIndexedBlock = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "latest_indexed_block",
+		Help:      "Highest block number that has been indexed.",
+	})
+	Lag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "lag_blocks",
+		Help:      "Number of blocks between chain head and latest indexed block.",
+	})
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_depth",
+		Help:      "Number of block numbers in block processor queue.",
+	})
+
+	BlocksIndexed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocks_indexed_total",
+		Help:      "Number of blocks indexed.",
+	})
+	TransactionsIndexed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_indexed_total",
+		Help:      "Number of transactions indexed.",
+	})
+	EventsIndexed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_indexed_total",
+		Help:      "Number of events indexed.",
+	})
+	Failures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failures_total",
+		Help:      "Number of failures of block processing by stage.",
+	}, []string{"stage"})
+
+	RPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Latency of requests to blockchain node by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+	DBDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_duration_seconds",
+		Help:      "Latency of database operations by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+)
+
+// ObserveRPC function that observe latency of rpc method since start,
+// it is used with defer e.g. `defer metrics.ObserveRPC("eth_getBlockByNumber", time.Now())`
+func ObserveRPC(method string, start time.Time) {
+	RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveDB function that observe latency of database operation since start
+func ObserveDB(operation string, start time.Time) {
+	DBDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// chainHead and latestIndexedBlock are held to calculate lag, gauges can't be read
+var (
+	chainHead          uint64
+	latestIndexedBlock uint64
+)
+
+// SetChainHead function that set chain head and update lag
+func SetChainHead(number uint64) {
+	atomic.StoreUint64(&chainHead, number)
+	ChainHead.Set(float64(number))
+	updateLag()
+}
+
+// SetLatestIndexedBlock function that set latest indexed block if it is higher than current value and update lag
+func SetLatestIndexedBlock(number uint64) {
+	// blocks can be indexed out of order, gauge only keeps the highest number
+	for {
+		current := atomic.LoadUint64(&latestIndexedBlock)
+		if number <= current {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&latestIndexedBlock, current, number) {
+			break
+		}
+	}
+
+	LatestIndexedBlock.Set(float64(number))
+	updateLag()
+}
+
+func updateLag() {
+	Lag.Set(float64(atomic.LoadUint64(&chainHead)) - float64(atomic.LoadUint64(&latestIndexedBlock)))
+}
+
+// Start function that serves `/metrics` endpoint in background,
+// it returns function to stop the server
+func Start(addr string) func(ctx context.Context) error {
+	log.Printf("starting metrics server on %s\n", addr)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ metrics server stopped : %s\n", err.Error())
+		}
+	}()
+
+	return server.Shutdown
+}
@@ -0,0 +1,20 @@
+package queue
+
+// numberHeap is min-heap of block numbers, it implements heap.Interface
+type numberHeap []uint64
+
+func (h numberHeap) Len() int           { return len(h) }
+func (h numberHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h numberHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *numberHeap) Push(x interface{}) {
+	*h = append(*h, x.(uint64))
+}
+
+func (h *numberHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
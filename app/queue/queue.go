@@ -1,65 +1,139 @@
 package queue
 
 import (
+	"container/heap"
+	"context"
+	"go-evm-indexer/app/metrics"
 	"go-evm-indexer/config"
+	"sync"
 	"time"
 )
 
+// State is processing state of block number in queue
+type State int
+
+const (
+	StatePending State = iota
+	StateInProgress
+	StateFailed
+	StateDone
+)
+
+func (s State) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateInProgress:
+		return "in-progress"
+	case StateFailed:
+		return "failed"
+	case StateDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// Backoff of failed block number, it is doubled on every attempt until maximum backoff
+const (
+	initialBackoff = 2 * time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
 type Block struct {
-	ConfirmedProgress bool
-	ConfirmedDone     bool
+	State State
+	// Attempts is number of failed attempts
+	Attempts int
+	// RetryAt is time that failed block number can be handed out again
+	RetryAt time.Time
 }
 
+// BlockProcessorQueue is concurrency-safe queue of block numbers,
+// block numbers that are ready to process will be handed out lowest number first
 type BlockProcessorQueue struct {
-	Blocks            map[uint64]*Block
-	LatestBlockNumber uint64
+	mutex sync.Mutex
+
+	blocks map[uint64]*Block
+	// ready holds block numbers that are pending or failed, ordered by block number
+	ready             numberHeap
+	latestBlockNumber uint64
 }
 
 // New function that new instance of queue, to be
 // invoked during setting up application
 func New() *BlockProcessorQueue {
 	return &BlockProcessorQueue{
-		Blocks:            make(map[uint64]*Block),
-		LatestBlockNumber: 0,
+		blocks: make(map[uint64]*Block),
 	}
 }
 
-// Start function that the successful block number will be cleared
-func (b *BlockProcessorQueue) Start() {
+// Start function that the successful block number will be cleared until context is canceled
+func (b *BlockProcessorQueue) Start(ctx context.Context) {
 	go func() {
-		for {
-			for number := range b.Blocks {
-				if b.Blocks[number].ConfirmedDone {
-					delete(b.Blocks, number)
-				}
-			}
+		for ctx.Err() == nil {
+			metrics.QueueDepth.Set(float64(b.clean()))
 
 			time.Sleep(100 * time.Millisecond)
 		}
 	}()
 }
 
+// clean function that removes done block numbers and returns number of remaining block numbers
+func (b *BlockProcessorQueue) clean() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for number, block := range b.blocks {
+		if block.State == StateDone {
+			delete(b.blocks, number)
+		}
+	}
+
+	return len(b.blocks)
+}
+
 func (b *BlockProcessorQueue) SetLatestBlockNumber(number uint64) {
-	b.LatestBlockNumber = number
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.latestBlockNumber = number
 }
 
-// ConfirmNext function that find the next block number that is ready to confirm
-// if block number over than (lastest block number - number of confirmations) it will pick up to run
+// ConfirmNext function that find the lowest block number that is ready to confirm
+// if block number over than (lastest block number - number of confirmations) it will pick up to run,
+// failed block numbers are skipped until their backoff is passed
 func (b *BlockProcessorQueue) ConfirmNext() (uint64, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	// Not pick up block numbers that are less than the number of confirmations
-	if b.LatestBlockNumber < config.Get().NumberOfConfirmations {
+	if b.latestBlockNumber < config.Get().NumberOfConfirmations {
 		return 0, false
 	}
 
-	for num := range b.Blocks {
-		if b.Blocks[num].ConfirmedDone || b.Blocks[num].ConfirmedProgress {
-			continue
+	var (
+		now     = time.Now()
+		waiting []uint64
+	)
+
+	// Block numbers that are waiting for backoff will be pushed back after found
+	defer func() {
+		for _, num := range waiting {
+			heap.Push(&b.ready, num)
 		}
+	}()
 
-		if b.LatestBlockNumber-config.Get().NumberOfConfirmations >= num {
-			b.Blocks[num].ConfirmedProgress = true
-			return num, true
+	for len(b.ready) > 0 && b.ready[0] <= b.latestBlockNumber-config.Get().NumberOfConfirmations {
+		num := heap.Pop(&b.ready).(uint64)
+
+		block := b.blocks[num]
+		if block.State == StateFailed && now.Before(block.RetryAt) {
+			waiting = append(waiting, num)
+			continue
 		}
+
+		block.State = StateInProgress
+		return num, true
 	}
 
 	return 0, false
@@ -67,32 +141,161 @@ func (b *BlockProcessorQueue) ConfirmNext() (uint64, bool) {
 
 // Put set block number to queue
 func (b *BlockProcessorQueue) Put(num uint64) bool {
-	if _, ok := b.Blocks[num]; ok {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.blocks[num]; ok {
 		return false
 	}
 
-	b.Blocks[num] = &Block{}
+	b.blocks[num] = &Block{State: StatePending}
+	heap.Push(&b.ready, num)
 
 	return true
 }
 
-func (b *BlockProcessorQueue) ConfirmedFailed(number uint64) bool {
-	block, ok := b.Blocks[number]
+// Requeue function that puts block number back to queue as pending without counting attempt, it is used when
+// block has to be processed again after chain reorganization. Block number that is in progress or done is
+// handed out again, it returns false if block number is already waiting in queue
+func (b *BlockProcessorQueue) Requeue(number uint64) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	block, ok := b.blocks[number]
 	if !ok {
+		block = &Block{}
+		b.blocks[number] = block
+	} else if block.State == StatePending || block.State == StateFailed {
 		return false
 	}
 
-	block.ConfirmedProgress = false
+	block.State = StatePending
+	heap.Push(&b.ready, number)
+
 	return true
 }
 
-func (b *BlockProcessorQueue) ConfirmedDone(number uint64) bool {
-	block, ok := b.Blocks[number]
+// ConfirmedFailed function that marks block number as failed, it will be handed out again after backoff.
+// Block number that is not in queue will be added as failed block number.
+//
+// It returns number of attempts and false when attempts reached maximum retries,
+// in that case block number is removed from queue and will not be retried
+func (b *BlockProcessorQueue) ConfirmedFailed(number uint64) (int, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	block, ok := b.blocks[number]
 	if !ok {
+		block = &Block{}
+		b.blocks[number] = block
+	} else if block.State != StateInProgress {
+		return block.Attempts, true
+	}
+
+	block.Attempts++
+	// Block number is retried forever when maximum retries is not set
+	if config.Get().MaxRetries > 0 && block.Attempts >= config.Get().MaxRetries {
+		delete(b.blocks, number)
+		return block.Attempts, false
+	}
+
+	block.State = StateFailed
+	block.RetryAt = time.Now().Add(backoff(block.Attempts))
+	heap.Push(&b.ready, number)
+
+	return block.Attempts, true
+}
+
+// backoff function that returns backoff duration of number of attempts
+func backoff(attempts int) time.Duration {
+	d := initialBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > maxBackoff {
+		return maxBackoff
+	}
+
+	return d
+}
+
+// ConfirmedDone function that marks in-progress block number as done, it will be removed by cleaner
+func (b *BlockProcessorQueue) ConfirmedDone(number uint64) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	block, ok := b.blocks[number]
+	if !ok || block.State != StateInProgress {
 		return false
 	}
 
-	block.ConfirmedProgress = false
-	block.ConfirmedDone = true
+	block.State = StateDone
+
 	return true
 }
+
+// State function that returns state of block number, false if block number is not in queue
+func (b *BlockProcessorQueue) State(number uint64) (State, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	block, ok := b.blocks[number]
+	if !ok {
+		return 0, false
+	}
+
+	return block.State, true
+}
+
+// Len function that returns number of block numbers in queue
+func (b *BlockProcessorQueue) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.blocks)
+}
+
+// Pending function that returns number of block numbers in queue that are not done yet
+func (b *BlockProcessorQueue) Pending() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var n int
+	for _, block := range b.blocks {
+		if block.State != StateDone {
+			n++
+		}
+	}
+
+	return n
+}
+
+// DropFailed function that removes failed block numbers that reached maximum attempts from queue,
+// it returns removed block numbers. Those are not handed out anymore
+func (b *BlockProcessorQueue) DropFailed(maxAttempts int) []uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var dropped []uint64
+	for number, block := range b.blocks {
+		if block.State == StateFailed && block.Attempts >= maxAttempts {
+			delete(b.blocks, number)
+			dropped = append(dropped, number)
+		}
+	}
+
+	// Dropped block numbers are removed from ready block numbers too
+	if len(dropped) > 0 {
+		ready := b.ready[:0]
+		for _, number := range b.ready {
+			if _, ok := b.blocks[number]; ok {
+				ready = append(ready, number)
+			}
+		}
+		b.ready = ready
+		heap.Init(&b.ready)
+	}
+
+	return dropped
+}
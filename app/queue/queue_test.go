@@ -0,0 +1,369 @@
+package queue
+
+import (
+	"go-evm-indexer/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setConfig function that loads config with number of confirmations and maximum retries for tests
+func setConfig(t *testing.T, confirmations uint64, maxRetries int) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "test.env")
+	content := "NUMBER_OF_CONFIRMATIONS=" + strconv.FormatUint(confirmations, 10) + "\nMAX_RETRIES=" + strconv.Itoa(maxRetries) + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config : %s", err.Error())
+	}
+
+	config.Read(file)
+}
+
+// confirmAll function that hands out all block numbers that are ready to confirm
+func confirmAll(q *BlockProcessorQueue) []uint64 {
+	var out []uint64
+	for {
+		number, ok := q.ConfirmNext()
+		if !ok {
+			return out
+		}
+		out = append(out, number)
+	}
+}
+
+func TestConfirmNextOrder(t *testing.T) {
+	setConfig(t, 2, 5)
+
+	tests := []struct {
+		name   string
+		put    []uint64
+		latest uint64
+		want   []uint64
+	}{
+		{name: "lowest number first", put: []uint64{7, 3, 9, 1, 5}, latest: 11, want: []uint64{1, 3, 5, 7, 9}},
+		{name: "unconfirmed numbers are held", put: []uint64{10, 8, 9, 4}, latest: 10, want: []uint64{4, 8}},
+		{name: "nothing before confirmations", put: []uint64{0, 1}, latest: 1, want: nil},
+		{name: "duplicate numbers are handed out once", put: []uint64{2, 2, 1, 2}, latest: 5, want: []uint64{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New()
+			for _, number := range tt.put {
+				q.Put(number)
+			}
+			q.SetLatestBlockNumber(tt.latest)
+
+			got := confirmAll(q)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmedFailed(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		failures   int
+		wantRetry  bool
+		wantQueued bool
+	}{
+		{name: "retried after first failure", maxRetries: 3, failures: 1, wantRetry: true, wantQueued: true},
+		{name: "retried before maximum retries", maxRetries: 3, failures: 2, wantRetry: true, wantQueued: true},
+		{name: "dropped at maximum retries", maxRetries: 3, failures: 3, wantRetry: false, wantQueued: false},
+		{name: "retried forever without maximum retries", maxRetries: 0, failures: 10, wantRetry: true, wantQueued: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, 0, tt.maxRetries)
+
+			q := New()
+			q.SetLatestBlockNumber(100)
+			q.Put(42)
+
+			var (
+				attempts int
+				retry    bool
+			)
+			for i := 0; i < tt.failures; i++ {
+				number, ok := q.ConfirmNext()
+				if !ok || number != 42 {
+					t.Fatalf("attempt %d : block is not handed out", i+1)
+				}
+
+				attempts, retry = q.ConfirmedFailed(42)
+
+				// Backoff is skipped to hand out block again
+				q.mutex.Lock()
+				if block, ok := q.blocks[42]; ok {
+					block.RetryAt = time.Time{}
+				}
+				q.mutex.Unlock()
+			}
+
+			if attempts != tt.failures || retry != tt.wantRetry {
+				t.Fatalf("got [ attempts : %d ] [ retry : %v ], want [ attempts : %d ] [ retry : %v ]", attempts, retry, tt.failures, tt.wantRetry)
+			}
+
+			state, ok := q.State(42)
+			if ok != tt.wantQueued {
+				t.Fatalf("got queued %v, want %v", ok, tt.wantQueued)
+			}
+			if ok && state != StateFailed {
+				t.Fatalf("got state %s, want %s", state, StateFailed)
+			}
+		})
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	setConfig(t, 0, 5)
+
+	tests := []struct {
+		name string
+		// prepare moves block number 7 to state before it is requeued
+		prepare   func(q *BlockProcessorQueue)
+		want      bool
+		wantState State
+	}{
+		{name: "not in queue", prepare: func(q *BlockProcessorQueue) {}, want: true, wantState: StatePending},
+		{name: "pending", prepare: func(q *BlockProcessorQueue) { q.Put(7) }, want: false, wantState: StatePending},
+		{name: "in progress", prepare: func(q *BlockProcessorQueue) { q.Put(7); q.ConfirmNext() }, want: true, wantState: StatePending},
+		{name: "done", prepare: func(q *BlockProcessorQueue) { q.Put(7); q.ConfirmNext(); q.ConfirmedDone(7) }, want: true, wantState: StatePending},
+		{name: "failed", prepare: func(q *BlockProcessorQueue) { q.Put(7); q.ConfirmNext(); q.ConfirmedFailed(7) }, want: false, wantState: StateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New()
+			q.SetLatestBlockNumber(100)
+			tt.prepare(q)
+
+			if got := q.Requeue(7); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			state, ok := q.State(7)
+			if !ok || state != tt.wantState {
+				t.Fatalf("got state %s, want %s", state, tt.wantState)
+			}
+
+			// Block number is handed out once even though it was requeued
+			q.mutex.Lock()
+			q.blocks[7].RetryAt = time.Time{}
+			q.mutex.Unlock()
+
+			if got := confirmAll(q); len(got) != 1 || got[0] != 7 {
+				t.Fatalf("got %v, want [7]", got)
+			}
+		})
+	}
+}
+
+func TestPendingAndDropFailed(t *testing.T) {
+	// Failed block numbers are retried forever without maximum retries
+	setConfig(t, 0, 0)
+
+	q := New()
+	q.SetLatestBlockNumber(100)
+	for _, number := range []uint64{1, 2, 3, 4} {
+		q.Put(number)
+	}
+
+	// 1 is done, 2 failed twice, 3 failed once and 4 is pending
+	q.ConfirmNext()
+	q.ConfirmedDone(1)
+	for i := 0; i < 2; i++ {
+		q.mutex.Lock()
+		q.blocks[2].RetryAt = time.Time{}
+		q.mutex.Unlock()
+
+		if number, ok := q.ConfirmNext(); !ok || number != 2 {
+			t.Fatalf("got %d, want 2", number)
+		}
+		q.ConfirmedFailed(2)
+	}
+	q.ConfirmNext()
+	q.ConfirmedFailed(3)
+
+	// Done block number is not pending even though it has not been cleaned yet
+	if got := q.Pending(); got != 3 {
+		t.Fatalf("got pending %d, want 3", got)
+	}
+
+	if got := q.DropFailed(2); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("got dropped %v, want [2]", got)
+	}
+
+	if got := q.Pending(); got != 2 {
+		t.Fatalf("got pending %d, want 2", got)
+	}
+
+	// Dropped block number is not handed out anymore
+	q.mutex.Lock()
+	q.blocks[3].RetryAt = time.Time{}
+	q.mutex.Unlock()
+
+	if got := confirmAll(q); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Fatalf("got %v, want [3 4]", got)
+	}
+}
+
+func TestBackoffIsHonored(t *testing.T) {
+	setConfig(t, 0, 5)
+
+	q := New()
+	q.SetLatestBlockNumber(100)
+	q.Put(1)
+	q.Put(2)
+
+	if number, _ := q.ConfirmNext(); number != 1 {
+		t.Fatalf("got %d, want 1", number)
+	}
+	q.ConfirmedFailed(1)
+
+	// Failed block is skipped while waiting for backoff but other blocks are handed out
+	if got := confirmAll(q); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("got %v, want [2]", got)
+	}
+
+	q.mutex.Lock()
+	q.blocks[1].RetryAt = time.Now().Add(-time.Millisecond)
+	q.mutex.Unlock()
+
+	if got := confirmAll(q); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("got %v, want [1] after backoff", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: initialBackoff},
+		{attempts: 2, want: 2 * initialBackoff},
+		{attempts: 3, want: 4 * initialBackoff},
+		{attempts: 100, want: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestConcurrentAccess is run with `go test -race`, every block number must be handed out and done exactly once
+// while numbers are put, confirmed, failed and done from many goroutines
+func TestConcurrentAccess(t *testing.T) {
+	setConfig(t, 0, 0)
+
+	const (
+		producers = 8
+		workers   = 8
+		perWorker = 200
+		total     = producers * perWorker
+	)
+
+	var (
+		q       = New()
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		done    = make(map[uint64]int)
+		failed  = make(map[uint64]bool)
+		stopped = make(chan struct{})
+	)
+
+	q.SetLatestBlockNumber(total)
+
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				q.Put(uint64(p*perWorker + i))
+			}
+		}(p)
+	}
+
+	var workersWg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		workersWg.Add(1)
+		go func() {
+			defer workersWg.Done()
+			for {
+				select {
+				case <-stopped:
+					return
+				default:
+				}
+
+				number, ok := q.ConfirmNext()
+				if !ok {
+					continue
+				}
+
+				mutex.Lock()
+				// Every block number fails once and then its backoff is skipped
+				if !failed[number] {
+					failed[number] = true
+					mutex.Unlock()
+
+					q.ConfirmedFailed(number)
+					q.mutex.Lock()
+					q.blocks[number].RetryAt = time.Time{}
+					q.mutex.Unlock()
+					continue
+				}
+				done[number]++
+				mutex.Unlock()
+
+				if !q.ConfirmedDone(number) {
+					t.Errorf("block %d is not in progress", number)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mutex.Lock()
+		n := len(done)
+		mutex.Unlock()
+
+		if n == total || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stopped)
+	workersWg.Wait()
+
+	if len(done) != total {
+		t.Fatalf("got %d done blocks, want %d", len(done), total)
+	}
+	for number, count := range done {
+		if count != 1 {
+			t.Fatalf("block %d is done %d times", number, count)
+		}
+	}
+
+	if n := q.clean(); n != 0 {
+		t.Fatalf("got %d remaining blocks after clean, want 0", n)
+	}
+}
@@ -0,0 +1,128 @@
+package rpcpool
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errorRateWeight is weight of the latest request when calculating error rate of endpoint
+const errorRateWeight = 0.2
+
+// Endpoint connection to one blockchain node
+type Endpoint struct {
+	url string
+
+	// raw and eth are nil until endpoint is connected and its chain id is verified, those are not changed after that
+	raw *rpc.Client
+	eth *ethclient.Client
+
+	mutex sync.RWMutex
+	// connected is true after endpoint is connected and its chain id is verified
+	connected bool
+	// head is latest block number of node from the last health check
+	head uint64
+	// alive is false when the last health check was failed
+	alive bool
+	// errorRate is exponential moving average of failed requests, from 0 to 1
+	errorRate float64
+}
+
+func newEndpoint(url string) *Endpoint {
+	return &Endpoint{url: url}
+}
+
+// connect function that dials node and verifies its chain id, chain id of node is returned when
+// expected chain id is nil. It does nothing if endpoint is already connected
+func (e *Endpoint) connect(ctx context.Context, chainID *big.Int) (*big.Int, error) {
+	if connected, _ := e.isConnected(); connected {
+		return chainID, nil
+	}
+
+	raw, err := rpc.DialContext(ctx, e.url)
+	if err != nil {
+		return nil, err
+	}
+
+	eth := ethclient.NewClient(raw)
+
+	id, err := eth.ChainID(ctx)
+	if err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("failed to get chain id : %s", err.Error())
+	}
+
+	if chainID != nil && chainID.Cmp(id) != 0 {
+		raw.Close()
+		return nil, fmt.Errorf("chain id [%s] is not matched with chain id [%s]", id, chainID)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.raw, e.eth = raw, eth
+	e.connected, e.alive = true, true
+
+	return id, nil
+}
+
+func (e *Endpoint) isConnected() (bool, *rpc.Client) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.connected, e.raw
+}
+
+// record function that updates error rate of endpoint by result of request
+func (e *Endpoint) record(failed bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.recordLocked(failed)
+}
+
+func (e *Endpoint) recordLocked(failed bool) {
+	value := 0.0
+	if failed {
+		value = 1
+	}
+	e.errorRate = e.errorRate*(1-errorRateWeight) + value*errorRateWeight
+}
+
+// check function that fetches latest block number of node to update health of endpoint, endpoint that is not
+// connected yet is connected first. Successful check is counted as successful request, so error rate of endpoint
+// that is skipped by requests decays and it can be healthy again
+func (e *Endpoint) check(ctx context.Context, chainID *big.Int) {
+	if _, err := e.connect(ctx, chainID); err != nil {
+		return
+	}
+
+	head, err := e.eth.BlockNumber(ctx)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.alive = err == nil
+	if err == nil {
+		e.head = head
+		e.recordLocked(false)
+	}
+}
+
+func (e *Endpoint) state() (head uint64, alive bool, errorRate float64) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.head, e.alive, e.errorRate
+}
+
+// close function that closes connection of endpoint if it is connected
+func (e *Endpoint) close() {
+	if connected, raw := e.isConnected(); connected {
+		raw.Close()
+	}
+}
@@ -0,0 +1,312 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// healthCheckInterval is interval of checking head height of all endpoints
+	healthCheckInterval = 5 * time.Second
+	// maxHeadLag is number of blocks that endpoint can be behind the highest head and still be healthy
+	maxHeadLag = 5
+	// maxErrorRate is error rate that endpoint will be considered unhealthy
+	maxErrorRate = 0.5
+)
+
+// errNoEndpoints is returned when none of endpoints is connected
+var errNoEndpoints = errors.New("no connected endpoints")
+
+// Error codes of json-rpc, execution reverted is returned by `eth_call` and `eth_estimateGas`
+const (
+	codeParseError        = -32700
+	codeInvalidRequest    = -32600
+	codeMethodNotFound    = -32601
+	codeInvalidParams     = -32602
+	codeExecutionReverted = 3
+)
+
+// Pool pool of blockchain node endpoints, requests are load-balanced by round-robin between healthy endpoints
+// and will fail over to next endpoint when endpoint can't serve the request
+type Pool struct {
+	endpoints []*Endpoint
+	next      uint32
+	// chainID is chain id of nodes, endpoint that is on different chain is never used
+	chainID *big.Int
+	// checkInterval is interval of health check, it is shortened by tests
+	checkInterval time.Duration
+}
+
+// Dial function that connect to all urls at the same time, endpoints that are down are skipped and will be connected
+// by health check later. It returns error if none of urls can be connected or any node is on different chain
+func Dial(ctx context.Context, urls []string) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no endpoints")
+	}
+
+	p := &Pool{
+		endpoints:     make([]*Endpoint, len(urls)),
+		checkInterval: healthCheckInterval,
+	}
+
+	var (
+		chainIDs = make([]*big.Int, len(urls))
+		errs     = make([]error, len(urls))
+		wg       sync.WaitGroup
+	)
+
+	for i, url := range urls {
+		p.endpoints[i] = newEndpoint(url)
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			chainIDs[i], errs[i] = p.endpoints[i].connect(ctx, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	var down []string
+	for i := range p.endpoints {
+		if errs[i] != nil {
+			log.Printf("⚠️ endpoint is down, skipped [ endpoint : %d ] : %s\n", i, errs[i].Error())
+			down = append(down, errs[i].Error())
+			continue
+		}
+
+		if p.chainID != nil && p.chainID.Cmp(chainIDs[i]) != 0 {
+			p.Close()
+			return nil, fmt.Errorf("chain id [%s] of endpoint [%d] is not matched with chain id [%s]", chainIDs[i], i, p.chainID)
+		}
+		p.chainID = chainIDs[i]
+	}
+
+	if p.chainID == nil {
+		return nil, fmt.Errorf("no healthy endpoints : %s", strings.Join(down, ", "))
+	}
+
+	p.check(ctx)
+
+	return p, nil
+}
+
+// Start function that checks health of all endpoints in background until context is canceled,
+// single endpoint is checked as well so it is reconnected and its head is tracked
+func (p *Pool) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.check(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Pool) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.checkInterval)
+	defer cancel()
+
+	done := make(chan struct{}, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		go func(endpoint *Endpoint) {
+			endpoint.check(ctx, p.chainID)
+			done <- struct{}{}
+		}(endpoint)
+	}
+
+	for range p.endpoints {
+		<-done
+	}
+}
+
+// candidates function that returns endpoints in order to try, healthy endpoints come first
+// and rotate by round-robin, then unhealthy endpoints sorted by error rate. Endpoints that are not connected are skipped
+func (p *Pool) candidates() []*Endpoint {
+	// There is nothing to select when there is only one endpoint
+	if len(p.endpoints) == 1 {
+		if ok, _ := p.endpoints[0].isConnected(); !ok {
+			return nil
+		}
+
+		return p.endpoints
+	}
+
+	var (
+		connected []*Endpoint
+		highest   uint64
+	)
+	for _, endpoint := range p.endpoints {
+		if ok, _ := endpoint.isConnected(); !ok {
+			continue
+		}
+		connected = append(connected, endpoint)
+
+		if head, alive, _ := endpoint.state(); alive && head > highest {
+			highest = head
+		}
+	}
+
+	var healthy, unhealthy []*Endpoint
+	for _, endpoint := range connected {
+		head, alive, errorRate := endpoint.state()
+		if alive && head+maxHeadLag >= highest && errorRate < maxErrorRate {
+			healthy = append(healthy, endpoint)
+		} else {
+			unhealthy = append(unhealthy, endpoint)
+		}
+	}
+
+	out := make([]*Endpoint, 0, len(p.endpoints))
+	if len(healthy) > 0 {
+		offset := int(atomic.AddUint32(&p.next, 1) % uint32(len(healthy)))
+		out = append(out, healthy[offset:]...)
+		out = append(out, healthy[:offset]...)
+	}
+
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		_, _, a := unhealthy[i].state()
+		_, _, b := unhealthy[j].state()
+		return a < b
+	})
+
+	return append(out, unhealthy...)
+}
+
+// do function that calls fn with endpoints until it succeeds, it fails over to next endpoint
+// when endpoint is unavailable or doesn't have data yet
+func (p *Pool) do(ctx context.Context, fn func(endpoint *Endpoint) error) error {
+	err := errNoEndpoints
+	for _, endpoint := range p.candidates() {
+		err = fn(endpoint)
+		if err == nil {
+			endpoint.record(false)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		// Error response of node is returned as it is when the request itself is wrong,
+		// it will be the same on other endpoints
+		if isDeterministic(err) {
+			endpoint.record(false)
+			return err
+		}
+
+		// Node may be behind other nodes or not support method or subscription, it is not counted as failure
+		if !errors.Is(err, ethereum.NotFound) && !errors.Is(err, rpc.ErrNotificationsUnsupported) && !isMethodNotFound(err) {
+			endpoint.record(true)
+			log.Printf("⚠️ rpc request failed, fail over to next endpoint : %s\n", err.Error())
+		}
+	}
+
+	return err
+}
+
+func (p *Pool) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	var out *types.Block
+	err := p.do(ctx, func(endpoint *Endpoint) error {
+		var err error
+		out, err = endpoint.eth.BlockByNumber(ctx, number)
+		return err
+	})
+
+	return out, err
+}
+
+func (p *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	var out *types.Header
+	err := p.do(ctx, func(endpoint *Endpoint) error {
+		var err error
+		out, err = endpoint.eth.HeaderByNumber(ctx, number)
+		return err
+	})
+
+	return out, err
+}
+
+// CallContext function that performs raw json-rpc call
+func (p *Pool) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return p.do(ctx, func(endpoint *Endpoint) error {
+		return endpoint.raw.CallContext(ctx, result, method, args...)
+	})
+}
+
+// BatchCallContext function that sends all given requests as a single batch,
+// batch is sent again to next endpoint only when whole batch is failed
+func (p *Pool) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	return p.do(ctx, func(endpoint *Endpoint) error {
+		return endpoint.raw.BatchCallContext(ctx, batch)
+	})
+}
+
+// SubscribeNewHead function that subscribes to new block headers on the first endpoint that supports it
+func (p *Pool) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	var out ethereum.Subscription
+	err := p.do(ctx, func(endpoint *Endpoint) error {
+		var err error
+		out, err = endpoint.eth.SubscribeNewHead(ctx, ch)
+		return err
+	})
+
+	return out, err
+}
+
+// ChainID function that returns chain id of nodes
+func (p *Pool) ChainID(ctx context.Context) (*big.Int, error) {
+	var out *big.Int
+	err := p.do(ctx, func(endpoint *Endpoint) error {
+		var err error
+		out, err = endpoint.eth.ChainID(ctx)
+		return err
+	})
+
+	return out, err
+}
+
+// Close function that closes connections of all endpoints
+func (p *Pool) Close() {
+	for _, endpoint := range p.endpoints {
+		endpoint.close()
+	}
+}
+
+// isDeterministic function that checks error is json-rpc error that is caused by request itself like invalid params
+// or reverted execution, other errors like internal error or missing state may be succeeded on other endpoints
+func isDeterministic(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	switch rpcErr.ErrorCode() {
+	case codeParseError, codeInvalidRequest, codeInvalidParams, codeExecutionReverted:
+		return true
+	default:
+		return false
+	}
+}
+
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == codeMethodNotFound
+}
@@ -0,0 +1,284 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// codeError error of test node that is returned as json-rpc error with code
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string  { return "test error" }
+func (e *codeError) ErrorCode() int { return e.code }
+
+// testNode `eth` namespace of test node, `eth_call` fails with code of node when it is not 0
+type testNode struct {
+	chainID int64
+	code    int
+	calls   int32
+	// head is head height of node, it is 100 when it is not set
+	head uint64
+}
+
+func (n *testNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(n.chainID))
+}
+
+func (n *testNode) BlockNumber() hexutil.Uint64 {
+	if head := atomic.LoadUint64(&n.head); head != 0 {
+		return hexutil.Uint64(head)
+	}
+
+	return 100
+}
+
+func (n *testNode) Call() (string, error) {
+	atomic.AddInt32(&n.calls, 1)
+	if n.code != 0 {
+		return "", &codeError{code: n.code}
+	}
+
+	return "ok", nil
+}
+
+func startNode(t *testing.T, node *testNode) string {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatalf("failed to register test node : %s", err.Error())
+	}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	return httpServer.URL
+}
+
+// downURL url that refuses connections
+func downURL(t *testing.T) string {
+	t.Helper()
+
+	httpServer := httptest.NewServer(nil)
+	url := httpServer.URL
+	httpServer.Close()
+
+	return url
+}
+
+func TestDial(t *testing.T) {
+	tests := []struct {
+		name      string
+		urls      func(t *testing.T) []string
+		wantErr   bool
+		connected int
+	}{
+		{
+			name: "all endpoints are up",
+			urls: func(t *testing.T) []string {
+				return []string{startNode(t, &testNode{chainID: 1}), startNode(t, &testNode{chainID: 1})}
+			},
+			connected: 2,
+		},
+		{
+			name:      "down endpoint is skipped",
+			urls:      func(t *testing.T) []string { return []string{downURL(t), startNode(t, &testNode{chainID: 1})} },
+			connected: 1,
+		},
+		{
+			name:    "all endpoints are down",
+			urls:    func(t *testing.T) []string { return []string{downURL(t), downURL(t)} },
+			wantErr: true,
+		},
+		{
+			name: "different chain",
+			urls: func(t *testing.T) []string {
+				return []string{startNode(t, &testNode{chainID: 1}), startNode(t, &testNode{chainID: 5})}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Dial(context.Background(), tt.urls(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer p.Close()
+
+			if got := len(p.candidates()); got != tt.connected {
+				t.Fatalf("got %d connected endpoints, want %d", got, tt.connected)
+			}
+		})
+	}
+}
+
+func TestDownEndpointIsConnectedByHealthCheck(t *testing.T) {
+	// Address is reserved and released to start test node on the same url later
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen : %s", err.Error())
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	p, err := Dial(context.Background(), []string{"http://" + addr, startNode(t, &testNode{chainID: 1})})
+	if err != nil {
+		t.Fatalf("failed to dial : %s", err.Error())
+	}
+	defer p.Close()
+
+	if got := len(p.candidates()); got != 1 {
+		t.Fatalf("got %d connected endpoints, want 1", got)
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &testNode{chainID: 1}); err != nil {
+		t.Fatalf("failed to register test node : %s", err.Error())
+	}
+	defer server.Stop()
+
+	if listener, err = net.Listen("tcp", addr); err != nil {
+		t.Skipf("address is taken : %s", err.Error())
+	}
+
+	httpServer := &httptest.Server{Listener: listener, Config: &http.Server{Handler: server}}
+	httpServer.Start()
+	defer httpServer.Close()
+
+	p.check(context.Background())
+
+	if got := len(p.candidates()); got != 2 {
+		t.Fatalf("got %d connected endpoints after health check, want 2", got)
+	}
+}
+
+func TestSingleEndpointIsHealthChecked(t *testing.T) {
+	node := &testNode{chainID: 1}
+	p, err := Dial(context.Background(), []string{startNode(t, node)})
+	if err != nil {
+		t.Fatalf("failed to dial : %s", err.Error())
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p.checkInterval = 10 * time.Millisecond
+	p.Start(ctx)
+
+	atomic.StoreUint64(&node.head, 200)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if head, alive, _ := p.endpoints[0].state(); alive && head == 200 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("head of single endpoint is not updated by health check")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := len(p.candidates()); got != 1 {
+		t.Fatalf("got %d candidates, want 1", got)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         int
+		wantErr      bool
+		wantFailover bool
+	}{
+		{name: "invalid params is returned", code: -32602, wantErr: true, wantFailover: false},
+		{name: "execution reverted is returned", code: 3, wantErr: true, wantFailover: false},
+		{name: "internal error is failed over", code: -32603, wantFailover: true},
+		{name: "server error is failed over", code: -32000, wantFailover: true},
+		{name: "method not found is failed over", code: -32601, wantFailover: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				first  = &testNode{chainID: 1, code: tt.code}
+				second = &testNode{chainID: 1}
+			)
+
+			p, err := Dial(context.Background(), []string{startNode(t, first), startNode(t, second)})
+			if err != nil {
+				t.Fatalf("failed to dial : %s", err.Error())
+			}
+			defer p.Close()
+
+			// Round-robin starts from the first endpoint
+			p.next = uint32(len(p.endpoints) - 1)
+
+			var out string
+			err = p.CallContext(context.Background(), &out, "eth_call")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			var rpcErr rpc.Error
+			if err != nil && (!errors.As(err, &rpcErr) || rpcErr.ErrorCode() != tt.code) {
+				t.Fatalf("got error %v, want code %d", err, tt.code)
+			}
+
+			if first.calls != 1 {
+				t.Fatalf("first endpoint is called %d times, want 1", first.calls)
+			}
+
+			if failover := second.calls == 1; failover != tt.wantFailover {
+				t.Fatalf("got failover %v, want %v", failover, tt.wantFailover)
+			}
+		})
+	}
+}
+
+func TestErrorRateDecays(t *testing.T) {
+	p, err := Dial(context.Background(), []string{startNode(t, &testNode{chainID: 1})})
+	if err != nil {
+		t.Fatalf("failed to dial : %s", err.Error())
+	}
+	defer p.Close()
+
+	endpoint := p.endpoints[0]
+	for i := 0; i < 10; i++ {
+		endpoint.record(true)
+	}
+
+	if _, _, errorRate := endpoint.state(); errorRate < maxErrorRate {
+		t.Fatalf("got error rate %f, want unhealthy", errorRate)
+	}
+
+	for i := 0; i < 5; i++ {
+		p.check(context.Background())
+	}
+
+	if _, _, errorRate := endpoint.state(); errorRate >= maxErrorRate {
+		t.Fatalf("got error rate %f after health checks, want healthy", errorRate)
+	}
+}
@@ -1,14 +1,130 @@
 package app
 
 import (
+	"context"
+	"go-evm-indexer/app/sink"
+	"go-evm-indexer/config"
 	"go-evm-indexer/entity"
-
-	"go.mongodb.org/mongo-driver/mongo"
+	"go-evm-indexer/repository"
+	"go-evm-indexer/repository/postgres"
+	"log"
+	"strings"
 )
 
-func bootstrap() (*entity.BlockChainNodeConnection, *mongo.Client) {
+type repositories struct {
+	blocks               repository.IBlocksRepository
+	transactions         repository.ITransactionsRepository
+	events               repository.IEventsRepository
+	tokenTransfers       repository.ITokenTransfersRepository
+	internalTransactions repository.IInternalTransactionsRepository
+	failedBlocks         repository.IFailedBlocksRepository
+	syncState            repository.ISyncStateRepository
+	webhookSubscriptions repository.IWebhookSubscriptionsRepository
+	webhookDeliveries    repository.IWebhookDeliveriesRepository
+	balances             repository.IBalancesRepository
+	tokenBalances        repository.ITokenBalancesRepository
+	sinkMessages         repository.ISinkMessagesRepository
+
+	rollback repository.Rollback
+
+	// close disconnects database when shutting down
+	close func(ctx context.Context) error
+}
+
+func bootstrap() (*entity.BlockChainNodeConnection, *repositories) {
 	blockChainNodeConn := newBockChainNodeConnection()
+
+	switch config.Get().DBDriver {
+	case config.DBDriverMongo:
+		return blockChainNodeConn, newMongoRepositories()
+	case config.DBDriverPostgres:
+		return blockChainNodeConn, newPostgresRepositories()
+	default:
+		log.Fatalf("❌ unsupported db driver : %s\n", config.Get().DBDriver)
+	}
+
+	return nil, nil
+}
+
+// newSink function that creates sink of config, it returns nil when sink is disabled
+func newSink() sink.Sink {
+	switch config.Get().Sink {
+	case "":
+		return nil
+	case config.SinkKafka:
+		brokers := strings.Split(config.Get().KafkaBrokers, ",")
+		if config.Get().KafkaBrokers == "" {
+			log.Fatalf("❌ kafka brokers are required\n")
+		}
+
+		return sink.NewKafka(brokers, config.Get().KafkaTopic)
+	case config.SinkNATS:
+		snk, err := sink.NewNATS(config.Get().NATSURL, config.Get().NATSSubject)
+		if err != nil {
+			log.Fatalf("❌ failed to connect nats : %s\n", err.Error())
+		}
+
+		return snk
+	default:
+		log.Fatalf("❌ unsupported sink : %s\n", config.Get().Sink)
+	}
+
+	return nil
+}
+
+func newMongoRepositories() *repositories {
 	mongoClient := newMongoClient()
+	db := mongoClient.Database(config.Get().MongoDBName)
+
+	if err := repository.Migrate(context.Background(), db); err != nil {
+		log.Fatalf("❌ failed to migrate mongo : %s\n", err.Error())
+	}
+
+	return &repositories{
+		blocks:               repository.NewBlocksRepository(db),
+		transactions:         repository.NewTransactionsRepository(db),
+		events:               repository.NewEventsRepository(db),
+		tokenTransfers:       repository.NewTokenTransfersRepository(db),
+		internalTransactions: repository.NewInternalTransactionsRepository(db),
+		failedBlocks:         repository.NewFailedBlocksRepository(db),
+		syncState:            repository.NewSyncStateRepository(db),
+		webhookSubscriptions: repository.NewWebhookSubscriptionsRepository(db),
+		webhookDeliveries:    repository.NewWebhookDeliveriesRepository(db),
+		balances:             repository.NewBalancesRepository(db),
+		tokenBalances:        repository.NewTokenBalancesRepository(db),
+		sinkMessages:         repository.NewSinkMessagesRepository(db),
+
+		rollback: repository.NewRollback(mongoClient),
+
+		close: mongoClient.Disconnect,
+	}
+}
+
+func newPostgresRepositories() *repositories {
+	db := newPostgresClient()
+
+	if err := postgres.Migrate(context.Background(), db); err != nil {
+		log.Fatalf("❌ failed to migrate postgres : %s\n", err.Error())
+	}
+
+	return &repositories{
+		blocks:               postgres.NewBlocksRepository(db),
+		transactions:         postgres.NewTransactionsRepository(db),
+		events:               postgres.NewEventsRepository(db),
+		tokenTransfers:       postgres.NewTokenTransfersRepository(db),
+		internalTransactions: postgres.NewInternalTransactionsRepository(db),
+		failedBlocks:         postgres.NewFailedBlocksRepository(db),
+		syncState:            postgres.NewSyncStateRepository(db),
+		webhookSubscriptions: postgres.NewWebhookSubscriptionsRepository(db),
+		webhookDeliveries:    postgres.NewWebhookDeliveriesRepository(db),
+		balances:             postgres.NewBalancesRepository(db),
+		tokenBalances:        postgres.NewTokenBalancesRepository(db),
+		sinkMessages:         postgres.NewSinkMessagesRepository(db),
+
+		rollback: postgres.NewRollback(db),
 
-	return blockChainNodeConn, mongoClient
+		close: func(_ context.Context) error {
+			return db.Close()
+		},
+	}
 }
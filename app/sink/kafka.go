@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka sink that writes messages to topic, messages are partitioned by key
+// so all messages of the same block are in order
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+func NewKafka(brokers []string, topic string) *Kafka {
+	return &Kafka{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (k *Kafka) Publish(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	msgs := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		msgs[i] = kafka.Message{
+			Key:   []byte(message.Key),
+			Value: message.Value,
+			Headers: []kafka.Header{
+				{Key: "type", Value: []byte(message.Type)},
+			},
+		}
+	}
+
+	return k.writer.WriteMessages(ctx, msgs...)
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
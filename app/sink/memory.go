@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory sink that keeps published messages in memory, it is used for testing and can't be selected by config
+type Memory struct {
+	mutex    sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(_ context.Context, messages []Message) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.messages = append(m.messages, messages...)
+	return nil
+}
+
+// Messages function that returns copy of all published messages in order
+func (m *Memory) Messages() []Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,44 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS sink that publishes messages to subject `{subject}.{type}`, key of message is in `Key` header
+type NATS struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNATS(url string, subject string) (*NATS, error) {
+	conn, err := nats.Connect(url, nats.Name("go-evm-indexer"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATS{
+		conn:    conn,
+		subject: subject,
+	}, nil
+}
+
+// Publish function that publishes messages and waits for server to receive them
+func (n *NATS) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		msg := nats.NewMsg(n.subject + "." + message.Type)
+		msg.Header.Set("Key", message.Key)
+		msg.Data = message.Value
+
+		if err := n.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+
+	return n.conn.FlushWithContext(ctx)
+}
+
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
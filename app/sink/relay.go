@@ -0,0 +1,99 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"go-evm-indexer/app/metrics"
+	"go-evm-indexer/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+// batchSize number of stored messages that are published in one round
+const batchSize = 500
+
+// Relay publishes messages of outbox to sink in order, messages are deleted only after sink accepted them
+// so they are published at least once. Failed round is retried with the same messages, later messages
+// are not published until it succeeds
+type Relay struct {
+	sinkMessagesRepo repository.ISinkMessagesRepository
+	sink             Sink
+
+	wg sync.WaitGroup
+}
+
+func NewRelay(sinkMessagesRepo repository.ISinkMessagesRepository, sink Sink) *Relay {
+	return &Relay{
+		sinkMessagesRepo: sinkMessagesRepo,
+		sink:             sink,
+	}
+}
+
+// Start function that publishes stored messages in background until context is canceled
+func (r *Relay) Start(ctx context.Context) {
+	log.Println("starting sink relay")
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for ctx.Err() == nil {
+			n, err := r.relay(ctx)
+			if err != nil {
+				metrics.Failures.WithLabelValues(metrics.StageSink).Inc()
+				log.Printf("❌ %s\n", err.Error())
+			}
+
+			// Next round starts immediately if there may be more stored messages
+			if err == nil && n == batchSize {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+}
+
+// Wait function that waits for relay to stop after context is canceled
+func (r *Relay) Wait() {
+	r.wg.Wait()
+}
+
+// relay function that publishes one batch of stored messages and returns number of them
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	stored, err := r.sinkMessagesRepo.FindSinkMessages(ctx, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find sink messages from db : %s", err.Error())
+	}
+
+	if len(stored) == 0 {
+		return 0, nil
+	}
+
+	var (
+		messages = make([]Message, len(stored))
+		ids      = make([]string, len(stored))
+	)
+	for i, message := range stored {
+		messages[i] = Message{Key: message.Key, Type: message.Type, Value: message.Value}
+		ids[i] = message.ID
+	}
+
+	if err := r.sink.Publish(ctx, messages); err != nil {
+		return 0, fmt.Errorf("failed to publish messages to sink [ key : %s ] : %s", messages[0].Key, err.Error())
+	}
+
+	// Messages are deleted even if context is canceled, otherwise published messages would be published again
+	deleteCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := r.sinkMessagesRepo.DeleteSinkMessages(deleteCtx, ids); err != nil {
+		return 0, fmt.Errorf("failed to delete sink messages from db : %s", err.Error())
+	}
+
+	return len(stored), nil
+}
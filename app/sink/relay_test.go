@@ -0,0 +1,170 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"sort"
+	"testing"
+)
+
+// fakeSinkMessagesRepo outbox in memory
+type fakeSinkMessagesRepo struct {
+	repository.ISinkMessagesRepository
+	messages []models.SinkMessage
+}
+
+func (r *fakeSinkMessagesRepo) FindSinkMessages(_ context.Context, limit int64) ([]models.SinkMessage, error) {
+	out := append([]models.SinkMessage(nil), r.messages...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+
+		return out[i].Index < out[j].Index
+	})
+
+	if int64(len(out)) > limit {
+		out = out[:limit]
+	}
+
+	return out, nil
+}
+
+func (r *fakeSinkMessagesRepo) AddSinkMessages(_ context.Context, messages []*models.SinkMessage) error {
+	for _, message := range messages {
+		r.messages = append(r.messages, *message)
+	}
+
+	return nil
+}
+
+func (r *fakeSinkMessagesRepo) DeleteSinkMessages(_ context.Context, ids []string) error {
+	deleted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	var out []models.SinkMessage
+	for _, message := range r.messages {
+		if !deleted[message.ID] {
+			out = append(out, message)
+		}
+	}
+	r.messages = out
+
+	return nil
+}
+
+// failingSink sink that fails while err is set
+type failingSink struct {
+	*Memory
+	err error
+}
+
+func (f *failingSink) Publish(ctx context.Context, messages []Message) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	return f.Memory.Publish(ctx, messages)
+}
+
+func storeMessages(t *testing.T, repo *fakeSinkMessagesRepo, keys ...string) {
+	messages := make([]Message, len(keys))
+	for i, key := range keys {
+		messages[i] = Message{Key: key, Type: TypeBlock, Value: []byte(key)}
+	}
+
+	stored := Outbox(messages)
+	// Messages are stored in reverse order to check that those are published in order
+	for i := len(stored) - 1; i >= 0; i-- {
+		if err := repo.AddSinkMessages(context.Background(), stored[i:i+1]); err != nil {
+			t.Fatalf("failed to store messages : %s", err.Error())
+		}
+	}
+}
+
+func keys(messages []Message) []string {
+	out := make([]string, len(messages))
+	for i, message := range messages {
+		out[i] = message.Key
+	}
+
+	return out
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	repo := &fakeSinkMessagesRepo{}
+	snk := NewMemory()
+	storeMessages(t, repo, "1", "2", "3")
+
+	n, err := NewRelay(repo, snk).relay(context.Background())
+	if err != nil {
+		t.Fatalf("failed to relay : %s", err.Error())
+	}
+
+	if got := keys(snk.Messages()); n != 3 || len(got) != 3 || got[0] != "1" || got[1] != "2" || got[2] != "3" {
+		t.Fatalf("got %d published messages %v, want [1 2 3]", n, got)
+	}
+
+	if len(repo.messages) != 0 {
+		t.Fatalf("got %d messages left in outbox, want 0", len(repo.messages))
+	}
+}
+
+func TestRelayKeepsMessagesOnFailure(t *testing.T) {
+	repo := &fakeSinkMessagesRepo{}
+	snk := &failingSink{Memory: NewMemory(), err: errors.New("broker is down")}
+	relay := NewRelay(repo, snk)
+	storeMessages(t, repo, "1", "2")
+
+	if _, err := relay.relay(context.Background()); err == nil {
+		t.Fatalf("relay succeeded while sink is failing")
+	}
+
+	if len(repo.messages) != 2 {
+		t.Fatalf("got %d messages left in outbox, want 2", len(repo.messages))
+	}
+
+	// The same messages are published in order after sink is recovered
+	snk.err = nil
+	storeMessages(t, repo, "3")
+
+	if _, err := relay.relay(context.Background()); err != nil {
+		t.Fatalf("failed to relay : %s", err.Error())
+	}
+
+	if got := keys(snk.Messages()); len(got) != 3 || got[0] != "1" || got[1] != "2" || got[2] != "3" {
+		t.Fatalf("got published messages %v, want [1 2 3]", got)
+	}
+
+	if len(repo.messages) != 0 {
+		t.Fatalf("got %d messages left in outbox, want 0", len(repo.messages))
+	}
+}
+
+func TestRelayBatches(t *testing.T) {
+	repo := &fakeSinkMessagesRepo{}
+	snk := NewMemory()
+	relay := NewRelay(repo, snk)
+
+	for i := 0; i < batchSize+1; i++ {
+		storeMessages(t, repo, "1")
+	}
+
+	for _, want := range []int{batchSize, 1, 0} {
+		n, err := relay.relay(context.Background())
+		if err != nil {
+			t.Fatalf("failed to relay : %s", err.Error())
+		}
+		if n != want {
+			t.Fatalf("got %d published messages in round, want %d", n, want)
+		}
+	}
+
+	if got := len(snk.Messages()); got != batchSize+1 {
+		t.Fatalf("got %d published messages, want %d", got, batchSize+1)
+	}
+}
@@ -0,0 +1,136 @@
+package sink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-evm-indexer/models"
+	"strconv"
+	"time"
+)
+
+// Types of message
+const (
+	TypeBlock       = "block"
+	TypeTransaction = "transaction"
+	TypeEvent       = "event"
+	// TypeRevert is published when block is rolled back, consumers should revert all records of the block
+	TypeRevert = "revert"
+)
+
+// Sink publishes records of indexed blocks to stream, messages are stored in outbox with records
+// and published by relay after those are committed to database
+type Sink interface {
+	Publish(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+// Message record to be published, messages of the same block have the same key
+type Message struct {
+	// Key is decimal string of block number
+	Key   string
+	Type  string
+	Value []byte
+}
+
+// envelope JSON value of message
+type envelope struct {
+	Type        string `json:"type"`
+	BlockNumber uint64 `json:"blockNumber"`
+	BlockHash   string `json:"blockHash"`
+	// Data is block, transaction or event, it is empty for revert
+	Data interface{} `json:"data,omitempty"`
+}
+
+func newMessage(typ string, blockNumber uint64, blockHash string, data interface{}) (Message, error) {
+	value, err := json.Marshal(&envelope{
+		Type:        typ,
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		Data:        data,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Key:   strconv.FormatUint(blockNumber, 10),
+		Type:  typ,
+		Value: value,
+	}, nil
+}
+
+// BlockMessages function that returns messages of block, its transactions and its events in order
+func BlockMessages(block *models.Block, txs []*models.Transaction, events []*models.Event) ([]Message, error) {
+	messages := make([]Message, 0, 1+len(txs)+len(events))
+
+	message, err := newMessage(TypeBlock, block.Number, block.Hash, block)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, message)
+
+	for _, tx := range txs {
+		message, err := newMessage(TypeTransaction, block.Number, block.Hash, tx)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	for _, event := range events {
+		message, err := newMessage(TypeEvent, block.Number, block.Hash, event)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// RevertMessages function that returns revert messages of rolled back blocks
+func RevertMessages(blocks []models.Block) ([]Message, error) {
+	messages := make([]Message, 0, len(blocks))
+	for _, block := range blocks {
+		message, err := newMessage(TypeRevert, block.Number, block.Hash, nil)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// Outbox function that returns messages to be stored in outbox, those are published in the same order
+func Outbox(messages []Message) []*models.SinkMessage {
+	var (
+		now = time.Now().UnixNano()
+		out = make([]*models.SinkMessage, len(messages))
+	)
+
+	for i, message := range messages {
+		out[i] = &models.SinkMessage{
+			ID:        newID(),
+			Key:       message.Key,
+			Type:      message.Type,
+			Value:     message.Value,
+			CreatedAt: now,
+			Index:     i,
+		}
+	}
+
+	return out
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes : %s", err.Error()))
+	}
+
+	return hex.EncodeToString(b)
+}
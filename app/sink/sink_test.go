@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"encoding/json"
+	"go-evm-indexer/models"
+	"testing"
+)
+
+func TestBlockMessages(t *testing.T) {
+	block := &models.Block{Number: 12, Hash: "0xb"}
+	txs := []*models.Transaction{{Hash: "0x1"}, {Hash: "0x2"}}
+	events := []*models.Event{{TransactionHash: "0x1", Index: 0}}
+
+	messages, err := BlockMessages(block, txs, events)
+	if err != nil {
+		t.Fatalf("failed to create messages : %s", err.Error())
+	}
+
+	want := []string{TypeBlock, TypeTransaction, TypeTransaction, TypeEvent}
+	if len(messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(want))
+	}
+
+	for i, message := range messages {
+		if message.Type != want[i] || message.Key != "12" {
+			t.Fatalf("message %d : got [ type : %s ] [ key : %s ], want [ type : %s ] [ key : 12 ]", i, message.Type, message.Key, want[i])
+		}
+
+		var env envelope
+		if err := json.Unmarshal(message.Value, &env); err != nil {
+			t.Fatalf("failed to decode message : %s", err.Error())
+		}
+		if env.Type != message.Type || env.BlockNumber != 12 || env.BlockHash != "0xb" || env.Data == nil {
+			t.Fatalf("unexpected envelope : %+v", env)
+		}
+	}
+}
+
+func TestRevertMessages(t *testing.T) {
+	messages, err := RevertMessages([]models.Block{{Number: 7, Hash: "0x7"}, {Number: 8, Hash: "0x8"}})
+	if err != nil {
+		t.Fatalf("failed to create messages : %s", err.Error())
+	}
+
+	for i, want := range []string{"7", "8"} {
+		if messages[i].Type != TypeRevert || messages[i].Key != want {
+			t.Fatalf("message %d : got [ type : %s ] [ key : %s ], want [ type : %s ] [ key : %s ]", i, messages[i].Type, messages[i].Key, TypeRevert, want)
+		}
+
+		var env map[string]interface{}
+		if err := json.Unmarshal(messages[i].Value, &env); err != nil {
+			t.Fatalf("failed to decode message : %s", err.Error())
+		}
+		if _, ok := env["data"]; ok {
+			t.Fatalf("revert message has data : %s", messages[i].Value)
+		}
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	messages := []Message{
+		{Key: "1", Type: TypeBlock, Value: []byte("a")},
+		{Key: "1", Type: TypeTransaction, Value: []byte("b")},
+	}
+
+	stored := Outbox(messages)
+	if len(stored) != len(messages) {
+		t.Fatalf("got %d stored messages, want %d", len(stored), len(messages))
+	}
+
+	for i, message := range stored {
+		if message.ID == "" || message.Index != i || message.CreatedAt != stored[0].CreatedAt {
+			t.Fatalf("unexpected stored message : %+v", message)
+		}
+		if message.Key != messages[i].Key || message.Type != messages[i].Type || string(message.Value) != string(messages[i].Value) {
+			t.Fatalf("got %+v, want %+v", message, messages[i])
+		}
+	}
+
+	if stored[0].ID == stored[1].ID {
+		t.Fatalf("ids are not unique : %s", stored[0].ID)
+	}
+}
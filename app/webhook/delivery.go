@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"encoding/json"
+	"go-evm-indexer/models"
+	"sync"
+	"time"
+)
+
+// payload JSON body that is sent to subscriber
+type payload struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"`
+	SubscriptionID string `json:"subscriptionId"`
+	BlockNumber    uint64 `json:"blockNumber"`
+	BlockHash      string `json:"blockHash"`
+	// Data is transaction or event, it is empty for reorg
+	Data interface{} `json:"data,omitempty"`
+}
+
+// matcher matches events and transactions of subscription
+type matcher struct {
+	subscription models.WebhookSubscription
+	addresses    map[string]bool
+	topics       map[string]bool
+}
+
+func newMatcher(subscription models.WebhookSubscription) *matcher {
+	m := &matcher{
+		subscription: subscription,
+		addresses:    make(map[string]bool),
+		topics:       make(map[string]bool),
+	}
+
+	for _, address := range subscription.Addresses {
+		m.addresses[address] = true
+	}
+	for _, topic := range subscription.Topics {
+		m.topics[topic] = true
+	}
+
+	return m
+}
+
+func (m *matcher) matchEvent(event *models.Event) bool {
+	if len(m.addresses) > 0 && !m.addresses[event.Origin] {
+		return false
+	}
+
+	if len(m.topics) > 0 && (len(event.Topics) == 0 || !m.topics[event.Topics[0]]) {
+		return false
+	}
+
+	return true
+}
+
+// matchTransaction transactions are matched only if subscription doesn't have topics,
+// created contract is matched only for contract creation because it is zero address for other transactions
+func (m *matcher) matchTransaction(tx *models.Transaction) bool {
+	if len(m.topics) > 0 {
+		return false
+	}
+
+	if m.addresses[tx.From] || (tx.To != "" && m.addresses[tx.To]) {
+		return true
+	}
+
+	return tx.To == "" && m.addresses[tx.Contract]
+}
+
+// matchAny function that checks any of transactions or events is matched
+func (m *matcher) matchAny(txs []*models.Transaction, events []*models.Event) bool {
+	for _, tx := range txs {
+		if m.matchTransaction(tx) {
+			return true
+		}
+	}
+
+	for _, event := range events {
+		if m.matchEvent(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Deliveries function that returns pending deliveries of transactions and events of block that matched with subscriptions
+func Deliveries(subscriptions []models.WebhookSubscription, block *models.Block, txs []*models.Transaction, events []*models.Event) ([]*models.WebhookDelivery, error) {
+	var out []*models.WebhookDelivery
+
+	for _, subscription := range subscriptions {
+		m := newMatcher(subscription)
+
+		for _, tx := range txs {
+			if !m.matchTransaction(tx) {
+				continue
+			}
+
+			delivery, err := newDelivery(subscription.ID, models.WebhookTypeTransaction, block.Number, block.Hash, tx)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, delivery)
+		}
+
+		for _, event := range events {
+			if !m.matchEvent(event) {
+				continue
+			}
+
+			delivery, err := newDelivery(subscription.ID, models.WebhookTypeEvent, block.Number, block.Hash, event)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, delivery)
+		}
+	}
+
+	return out, nil
+}
+
+// ReorgDeliveries function that returns pending deliveries to notify subscriptions that block was reorged out,
+// only subscriptions that have matched transactions or events of block are notified.
+// Subscribers should revert transactions and events that they received of the block
+func ReorgDeliveries(subscriptions []models.WebhookSubscription, block *models.Block, txs []*models.Transaction, events []*models.Event) ([]*models.WebhookDelivery, error) {
+	var out []*models.WebhookDelivery
+
+	for _, subscription := range subscriptions {
+		if !newMatcher(subscription).matchAny(txs, events) {
+			continue
+		}
+
+		delivery, err := newDelivery(subscription.ID, models.WebhookTypeReorg, block.Number, block.Hash, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, delivery)
+	}
+
+	return out, nil
+}
+
+func newDelivery(subscriptionID string, typ string, blockNumber uint64, blockHash string, data interface{}) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		ID:             newID(),
+		SubscriptionID: subscriptionID,
+		Type:           typ,
+		BlockNumber:    blockNumber,
+		BlockHash:      blockHash,
+		State:          models.WebhookDeliveryPending,
+		CreatedAt:      time.Now().Unix(),
+		Sequence:       nextSequence(),
+	}
+	delivery.NextAttemptAt = delivery.CreatedAt
+
+	body, err := json.Marshal(&payload{
+		ID:             delivery.ID,
+		Type:           typ,
+		SubscriptionID: subscriptionID,
+		BlockNumber:    blockNumber,
+		BlockHash:      blockHash,
+		Data:           data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = body
+
+	return delivery, nil
+}
+
+var (
+	sequenceMutex sync.Mutex
+	lastSequence  int64
+)
+
+// nextSequence function that returns sequence of delivery from current time in nanoseconds,
+// delivery that is created later has greater sequence
+func nextSequence() int64 {
+	sequenceMutex.Lock()
+	defer sequenceMutex.Unlock()
+
+	sequence := time.Now().UnixNano()
+	if sequence <= lastSequence {
+		sequence = lastSequence + 1
+	}
+	lastSequence = sequence
+
+	return sequence
+}
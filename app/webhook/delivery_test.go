@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"go-evm-indexer/models"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+)
+
+func TestMatchTransaction(t *testing.T) {
+	var (
+		zero     = common.Address{}.Hex()
+		watched  = common.HexToAddress("0x1").Hex()
+		other    = common.HexToAddress("0x2").Hex()
+		created  = common.HexToAddress("0x3").Hex()
+		creation = &models.Transaction{From: other, To: "", Contract: created}
+	)
+
+	tests := []struct {
+		name      string
+		addresses []string
+		topics    []string
+		tx        *models.Transaction
+		want      bool
+	}{
+		{name: "from", addresses: []string{watched}, tx: &models.Transaction{From: watched, To: other, Contract: zero}, want: true},
+		{name: "to", addresses: []string{watched}, tx: &models.Transaction{From: other, To: watched, Contract: zero}, want: true},
+		{name: "not matched", addresses: []string{watched}, tx: &models.Transaction{From: other, To: other, Contract: zero}, want: false},
+		{name: "zero contract of call", addresses: []string{zero}, tx: &models.Transaction{From: other, To: other, Contract: zero}, want: false},
+		{name: "created contract", addresses: []string{created}, tx: creation, want: true},
+		{name: "subscription with topics", addresses: []string{watched}, topics: []string{common.Hash{1}.Hex()}, tx: &models.Transaction{From: watched, To: other}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMatcher(models.WebhookSubscription{Addresses: pq.StringArray(tt.addresses), Topics: pq.StringArray(tt.topics)})
+			if got := m.matchTransaction(tt.tx); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorgDeliveries(t *testing.T) {
+	var (
+		watched = common.HexToAddress("0x1").Hex()
+		other   = common.HexToAddress("0x2").Hex()
+		topic   = common.Hash{1}.Hex()
+		block   = &models.Block{Number: 7, Hash: common.Hash{7}.Hex()}
+		txs     = []*models.Transaction{{From: watched, To: other}}
+		events  = []*models.Event{{Origin: other, Topics: []string{topic}}}
+	)
+
+	subscriptions := []models.WebhookSubscription{
+		{ID: "from", Addresses: pq.StringArray{watched}},
+		{ID: "topic", Topics: pq.StringArray{topic}},
+		{ID: "not matched", Addresses: pq.StringArray{common.HexToAddress("0x3").Hex()}},
+		{ID: "address with other topic", Addresses: pq.StringArray{other}, Topics: pq.StringArray{common.Hash{2}.Hex()}},
+	}
+
+	deliveries, err := ReorgDeliveries(subscriptions, block, txs, events)
+	if err != nil {
+		t.Fatalf("failed to create deliveries : %s", err.Error())
+	}
+
+	var got []string
+	for _, delivery := range deliveries {
+		if delivery.Type != models.WebhookTypeReorg || delivery.BlockHash != block.Hash {
+			t.Fatalf("got delivery [ type : %s ] [ block : %s ], want reorg of block", delivery.Type, delivery.BlockHash)
+		}
+		got = append(got, delivery.SubscriptionID)
+	}
+
+	if want := []string{"from", "topic"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
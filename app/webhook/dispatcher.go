@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-evm-indexer/config"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gammazero/workerpool"
+)
+
+const (
+	// batchSize number of pending deliveries that are sent in one round
+	batchSize = 100
+	// concurrency number of subscriptions that deliveries are sent to at the same time
+	concurrency = 10
+	// requestTimeout timeout of a request to subscriber
+	requestTimeout = 10 * time.Second
+	// retention period that delivered deliveries are kept before being deleted
+	retention = 7 * 24 * time.Hour
+)
+
+// Backoff of failed delivery, it is doubled on every attempt until maximum backoff
+const (
+	initialBackoff = 10 * time.Second
+	maxBackoff     = time.Hour
+)
+
+// Headers of request to subscriber, signature is hex of HMAC-SHA256 of `{timestamp}.{body}` by secret of subscription
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Dispatcher sends pending deliveries of outbox to subscribers, delivery is marked as delivered only
+// after subscriber responds 2xx so it is delivered at least once
+type Dispatcher struct {
+	webhookSubscriptionsRepo repository.IWebhookSubscriptionsRepository
+	webhookDeliveriesRepo    repository.IWebhookDeliveriesRepository
+
+	client *http.Client
+
+	wg sync.WaitGroup
+}
+
+func NewDispatcher(
+	webhookSubscriptionsRepo repository.IWebhookSubscriptionsRepository,
+	webhookDeliveriesRepo repository.IWebhookDeliveriesRepository,
+) *Dispatcher {
+	return &Dispatcher{
+		webhookSubscriptionsRepo: webhookSubscriptionsRepo,
+		webhookDeliveriesRepo:    webhookDeliveriesRepo,
+
+		client: newClient(),
+	}
+}
+
+// newClient function that creates http client which connects only to public addresses
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: dialControl,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Proxy is not used, otherwise address of target would not be checked
+	transport.Proxy = nil
+
+	return &http.Client{Timeout: requestTimeout, Transport: transport}
+}
+
+// Start function that sends pending deliveries in background until context is canceled
+func (d *Dispatcher) Start(ctx context.Context) {
+	log.Println("starting webhook dispatcher")
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		var prunedAt time.Time
+		for ctx.Err() == nil {
+			if time.Since(prunedAt) > time.Hour {
+				if err := d.webhookDeliveriesRepo.DeleteDeliveredWebhookDeliveries(ctx, time.Now().Add(-retention).Unix()); err != nil {
+					log.Printf("❌ failed to delete delivered webhook deliveries from db : %s\n", err.Error())
+				}
+				prunedAt = time.Now()
+			}
+
+			n, err := d.dispatch(ctx)
+			if err != nil {
+				log.Printf("❌ %s\n", err.Error())
+			}
+
+			// Next round starts immediately if there may be more pending deliveries
+			if err == nil && n == batchSize {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+}
+
+// Wait function that waits for dispatcher to stop after context is canceled
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// dispatch function that sends one batch of pending deliveries and returns number of them.
+//
+// Deliveries of subscription are sent one by one in order of sequence, rest of them are not sent
+// when delivery is failed so subscriber never receives reorg before deliveries of orphaned block
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.webhookDeliveriesRepo.FindPendingWebhookDeliveries(ctx, time.Now().Unix(), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find pending webhook deliveries from db : %s", err.Error())
+	}
+
+	var (
+		subscriptions = make(map[string]*models.WebhookSubscription)
+		// ordered deliveries of each subscription, subscription ids are kept in order that they were found
+		ordered = make(map[string][]*models.WebhookDelivery)
+		ids     []string
+	)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		ordered[delivery.SubscriptionID] = append(ordered[delivery.SubscriptionID], delivery)
+
+		if _, ok := subscriptions[delivery.SubscriptionID]; ok {
+			continue
+		}
+
+		subscription, err := d.webhookSubscriptionsRepo.FindWebhookSubscriptionByID(ctx, delivery.SubscriptionID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find webhook subscription by id from db : %s", err.Error())
+		}
+		subscriptions[delivery.SubscriptionID] = subscription
+		ids = append(ids, delivery.SubscriptionID)
+	}
+
+	wp := workerpool.New(concurrency)
+	for _, id := range ids {
+		subscription, deliveries := subscriptions[id], ordered[id]
+
+		wp.Submit(func() {
+			for _, delivery := range deliveries {
+				d.deliver(ctx, subscription, delivery)
+
+				// Next delivery waits for failed delivery to be retried
+				if delivery.State == models.WebhookDeliveryPending || ctx.Err() != nil {
+					return
+				}
+			}
+		})
+	}
+	wp.StopWait()
+
+	return len(deliveries), nil
+}
+
+// deliver function that sends delivery and updates its state, delivery of deleted subscription will be failed
+func (d *Dispatcher) deliver(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	var err error
+	if subscription == nil {
+		delivery.State = models.WebhookDeliveryFailed
+		delivery.LastError = "subscription not found"
+	} else if err = d.send(ctx, subscription, delivery); err != nil {
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts)).Unix()
+
+		if maxAttempts := config.Get().WebhookMaxAttempts; maxAttempts > 0 && delivery.Attempts >= maxAttempts {
+			delivery.State = models.WebhookDeliveryFailed
+		}
+	} else {
+		delivery.Attempts++
+		delivery.State = models.WebhookDeliveryDelivered
+		delivery.LastError = ""
+	}
+
+	if err != nil {
+		log.Printf("❌ failed to send webhook [ delivery : %s ] [ attempts : %d ] : %s\n", delivery.ID, delivery.Attempts, err.Error())
+	}
+
+	// State is updated even if context is canceled, otherwise delivered payload would be sent again
+	updateCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := d.webhookDeliveriesRepo.UpdateWebhookDelivery(updateCtx, delivery); err != nil {
+		log.Printf("❌ failed to update webhook delivery [ delivery : %s ] : %s\n", delivery.ID, err.Error())
+	}
+}
+
+// send function that posts signed payload to subscriber
+func (d *Dispatcher) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code [ status : %d ]", res.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign function that returns hex of HMAC-SHA256 signature of payload, subscriber can verify payload by the same function
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff function that returns backoff duration of number of attempts
+func backoff(attempts int) time.Duration {
+	d := initialBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > maxBackoff {
+		return maxBackoff
+	}
+
+	return d
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type fakeSubscriptionsRepo struct {
+	repository.IWebhookSubscriptionsRepository
+	subscriptions map[string]*models.WebhookSubscription
+}
+
+func (r *fakeSubscriptionsRepo) FindWebhookSubscriptionByID(_ context.Context, id string) (*models.WebhookSubscription, error) {
+	return r.subscriptions[id], nil
+}
+
+type fakeDeliveriesRepo struct {
+	repository.IWebhookDeliveriesRepository
+	deliveries []models.WebhookDelivery
+}
+
+func (r *fakeDeliveriesRepo) FindPendingWebhookDeliveries(_ context.Context, _ int64, _ int64) ([]models.WebhookDelivery, error) {
+	return append([]models.WebhookDelivery(nil), r.deliveries...), nil
+}
+
+func (r *fakeDeliveriesRepo) UpdateWebhookDelivery(_ context.Context, _ *models.WebhookDelivery) error {
+	return nil
+}
+
+func TestDispatchInOrderOfSubscription(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		received = make(map[string][]string)
+	)
+
+	// Subscriber fails delivery `a2`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], r.Header.Get(HeaderID))
+		mutex.Unlock()
+
+		if r.Header.Get(HeaderID) == "a2" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	d := &Dispatcher{
+		webhookSubscriptionsRepo: &fakeSubscriptionsRepo{subscriptions: map[string]*models.WebhookSubscription{
+			"a": {ID: "a", URL: server.URL + "/a"},
+			"b": {ID: "b", URL: server.URL + "/b"},
+		}},
+		webhookDeliveriesRepo: &fakeDeliveriesRepo{deliveries: []models.WebhookDelivery{
+			{ID: "a1", SubscriptionID: "a", State: models.WebhookDeliveryPending, Sequence: 1},
+			{ID: "b1", SubscriptionID: "b", State: models.WebhookDeliveryPending, Sequence: 2},
+			{ID: "a2", SubscriptionID: "a", State: models.WebhookDeliveryPending, Sequence: 3},
+			{ID: "b2", SubscriptionID: "b", State: models.WebhookDeliveryPending, Sequence: 4},
+			{ID: "a3", SubscriptionID: "a", State: models.WebhookDeliveryPending, Sequence: 5},
+		}},
+		// Test server is on loopback address that is not allowed by client of dispatcher
+		client: http.DefaultClient,
+	}
+
+	n, err := d.dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("failed to dispatch : %s", err.Error())
+	}
+	if n != 5 {
+		t.Fatalf("got %d deliveries, want 5", n)
+	}
+
+	// Delivery after failed delivery is not sent until failed delivery is retried
+	want := map[string][]string{
+		"/a": {"a1", "a2"},
+		"/b": {"b1", "b2"},
+	}
+	if !reflect.DeepEqual(received, want) {
+		t.Fatalf("got %v, want %v", received, want)
+	}
+}
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-evm-indexer/models"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lib/pq"
+)
+
+// NewSubscription function that validates and creates subscription, url must be resolved to public addresses.
+// Random secret is generated if secret is empty
+//
+// Topic can be topic hash or text signature of event like `Transfer(address,address,uint256)`
+func NewSubscription(rawURL string, secret string, addresses []string, topics []string) (*models.WebhookSubscription, error) {
+	if err := validateURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	if len(addresses) == 0 && len(topics) == 0 {
+		return nil, fmt.Errorf("at least one address or topic is required")
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:        newID(),
+		URL:       rawURL,
+		Secret:    secret,
+		Addresses: pq.StringArray{},
+		Topics:    pq.StringArray{},
+		CreatedAt: time.Now().Unix(),
+	}
+
+	if subscription.Secret == "" {
+		subscription.Secret = newID() + newID()
+	}
+
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address : %s", address)
+		}
+
+		subscription.Addresses = append(subscription.Addresses, common.HexToAddress(address).Hex())
+	}
+
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		switch {
+		case strings.HasPrefix(topic, "0x") && len(topic) == 2*common.HashLength+2:
+			subscription.Topics = append(subscription.Topics, common.HexToHash(topic).Hex())
+		case strings.Contains(topic, "("):
+			subscription.Topics = append(subscription.Topics, crypto.Keccak256Hash([]byte(topic)).Hex())
+		default:
+			return nil, fmt.Errorf("invalid topic : %s", topic)
+		}
+	}
+
+	return subscription, nil
+}
+
+// newID function that returns random 16 bytes hex string
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes : %s", err.Error()))
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "8.8.8.8", want: true},
+		{ip: "2606:4700:4700::1111", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "::ffff:127.0.0.1", want: false},
+		{ip: "224.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://8.8.8.8/hook", wantErr: false},
+		{url: "http://[2606:4700:4700::1111]:8080/hook", wantErr: false},
+		{url: "http://127.0.0.1:8080/hook", wantErr: true},
+		{url: "http://localhost/hook", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "http://[::1]/hook", wantErr: true},
+		{url: "ftp://8.8.8.8/hook", wantErr: true},
+		{url: "http:///hook", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("validateURL(%s) = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	if err := dialControl("tcp", "127.0.0.1:80", nil); err != errPrivateTarget {
+		t.Fatalf("got %v, want %v", err, errPrivateTarget)
+	}
+
+	if err := dialControl("tcp", "8.8.8.8:443", nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
@@ -2,25 +2,72 @@ package config
 
 import (
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 var config Config
 
+const (
+	DBDriverMongo    = "mongo"
+	DBDriverPostgres = "postgres"
+)
+
+const (
+	SinkKafka = "kafka"
+	SinkNATS  = "nats"
+)
+
 type Config struct {
 	WebsocketURL          string `mapstructure:"WEBSOCKET_URL"`
 	RPCURL                string `mapstructure:"RPC_URL"`
+	WebsocketURLs         string `mapstructure:"WEBSOCKET_URLS"`
+	RPCURLs               string `mapstructure:"RPC_URLS"`
 	MongoURI              string `mapstructure:"MONGO_URI"`
 	MongoDBName           string `mapstructure:"MONGO_DB_NAME"`
+	DBDriver              string `mapstructure:"DB_DRIVER"`
+	PostgresURI           string `mapstructure:"POSTGRES_URI"`
 	Concurrency           int    `mapstructure:"CONCURRENCY"`
 	NumberOfConfirmations uint64 `mapstructure:"NUMBER_OF_CONFIRMATIONS"`
 	MaxJobTimeout         int    `mapstructure:"MAX_JOB_TIMEOUT"`
+	MaxRetries            int    `mapstructure:"MAX_RETRIES"`
+	RPCBatchSize          int    `mapstructure:"RPC_BATCH_SIZE"`
+	APIAddr               string `mapstructure:"API_ADDR"`
+	MetricsAddr           string `mapstructure:"METRICS_ADDR"`
+	ABIDir                string `mapstructure:"ABI_DIR"`
+	// TraceInternalTransactions enables tracing of internal transactions, node must support `debug_traceBlockByNumber`
+	TraceInternalTransactions bool `mapstructure:"TRACE_INTERNAL_TRANSACTIONS"`
+	// Comma separated filters of transactions and events to be stored, everything is stored when all of them are empty
+	WatchContracts       string `mapstructure:"WATCH_CONTRACTS"`
+	WatchEventSignatures string `mapstructure:"WATCH_EVENT_SIGNATURES"`
+	WatchFrom            string `mapstructure:"WATCH_FROM"`
+	WatchTo              string `mapstructure:"WATCH_TO"`
+	// WebhookMaxAttempts is number of attempts before webhook delivery is failed, 0 means unlimited
+	WebhookMaxAttempts int `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	// TrackBalances enables snapshots of balance and nonce of touched addresses, node must keep state of indexed blocks
+	TrackBalances bool `mapstructure:"TRACK_BALANCES"`
+	// Sink is stream that indexed records are published to, it is disabled when empty
+	Sink         string `mapstructure:"SINK"`
+	KafkaBrokers string `mapstructure:"KAFKA_BROKERS"`
+	KafkaTopic   string `mapstructure:"KAFKA_TOPIC"`
+	NATSURL      string `mapstructure:"NATS_URL"`
+	NATSSubject  string `mapstructure:"NATS_SUBJECT"`
+	// JSONRPCAddr is address of json-rpc server that answers `eth_*` methods from indexed data, it is disabled when empty
+	JSONRPCAddr string `mapstructure:"JSONRPC_ADDR"`
+	// AdminToken is bearer token of admin endpoints of api like webhooks and failed blocks, those endpoints are disabled when empty
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
 }
 
 func Read(file string) {
 	viper.SetDefault("MONGO_DB_NAME", "evm-indexer")
 	viper.SetDefault("MAX_JOB_TIMEOUT", 5)
+	viper.SetDefault("DB_DRIVER", DBDriverMongo)
+	viper.SetDefault("RPC_BATCH_SIZE", 100)
+	viper.SetDefault("MAX_RETRIES", 5)
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 10)
+	viper.SetDefault("KAFKA_TOPIC", "evm-indexer")
+	viper.SetDefault("NATS_SUBJECT", "evm-indexer")
 	viper.SetConfigFile(file)
 	viper.AutomaticEnv()
 	err := viper.ReadInConfig()
@@ -37,3 +84,37 @@ func Read(file string) {
 func Get() Config {
 	return config
 }
+
+// RPCEndpoints function that returns all rpc urls from `RPC_URL` and comma separated `RPC_URLS`
+func (c Config) RPCEndpoints() []string {
+	return joinURLs(c.RPCURL, c.RPCURLs)
+}
+
+// WebsocketEndpoints function that returns all websocket urls from `WEBSOCKET_URL` and comma separated `WEBSOCKET_URLS`
+func (c Config) WebsocketEndpoints() []string {
+	return joinURLs(c.WebsocketURL, c.WebsocketURLs)
+}
+
+// Filtered function that returns true when any watch filter is set, only part of chain data is indexed in that case
+func (c Config) Filtered() bool {
+	return c.WatchContracts != "" || c.WatchEventSignatures != "" || c.WatchFrom != "" || c.WatchTo != ""
+}
+
+func joinURLs(url, urls string) []string {
+	var (
+		out  []string
+		seen = make(map[string]bool)
+	)
+
+	for _, value := range append([]string{url}, strings.Split(urls, ",")...) {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+
+		seen[value] = true
+		out = append(out, value)
+	}
+
+	return out
+}
@@ -1,12 +1,29 @@
 package entity
 
-import "github.com/ethereum/go-ethereum/ethclient"
+import (
+	"go-evm-indexer/app/rpcpool"
+	"math/big"
+)
 
 type BlockChainNodeConnection struct {
-	RPC       *ethclient.Client
-	Websocket *ethclient.Client
+	// RPC is pool of rpc endpoints, it is also used to call methods that ethclient doesn't provide
+	// and batch requests
+	RPC *rpcpool.Pool
+	// Websocket is pool of websocket endpoints, it is nil when websocket urls are not set
+	Websocket *rpcpool.Pool
+	ChainID   *big.Int
 }
 
 type Job struct {
 	BlockNumber uint64
 }
+
+// Close function that closes all connections to blockchain node
+func (c *BlockChainNodeConnection) Close() {
+	if c.Websocket != nil {
+		c.Websocket.Close()
+	}
+	if c.RPC != nil {
+		c.RPC.Close()
+	}
+}
@@ -1,12 +1,25 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"go-evm-indexer/app"
 	"go-evm-indexer/config"
 	"log"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 )
 
+// usage of commands, indexer is run when command is not given
+const usage = `usage: go-evm-indexer [command]
+
+commands:
+  migrate                        re-index blocks that were stored by older schema version
+  backfill --from <n> --to <n>   index blocks in range and exit, it can be resumed by running again
+  rebuild-token-balances         regenerate token balances from stored token transfers, indexer must be stopped`
+
 func main() {
 	configFile, err := filepath.Abs(".env")
 	if err != nil {
@@ -14,6 +27,58 @@ func main() {
 	}
 	config.Read(configFile)
 
-	log.Println("running...")
-	app.Run()
+	// Root context is canceled on SIGINT or SIGTERM to stop indexer gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "":
+		log.Println("running...")
+		err = app.Run(ctx)
+	case "migrate":
+		log.Println("migrating...")
+		err = app.Migrate(ctx)
+	case "backfill":
+		from, to := parseBackfillFlags(os.Args[2:])
+		err = app.Backfill(ctx, from, to)
+	case "rebuild-token-balances":
+		log.Println("rebuilding token balances...")
+		err = app.RebuildTokenBalances(ctx)
+	default:
+		log.Fatalf("❌ unknown command `%s`\n%s\n", command, usage)
+	}
+
+	if err != nil {
+		log.Fatalf("❌ indexer stopped : %s\n", err.Error())
+	}
+	log.Println("stopped")
+}
+
+// parseBackfillFlags function that parses block range of backfill command
+func parseBackfillFlags(args []string) (uint64, uint64) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	from := fs.Uint64("from", 0, "first block number to index")
+	to := fs.Uint64("to", 0, "last block number to index")
+	fs.Parse(args)
+
+	// Block number 0 is a valid value of --to, so it is checked whether the flag is given
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	if !given["to"] {
+		log.Fatalf("❌ --to is required\n%s\n", usage)
+	}
+
+	if *to < *from {
+		log.Fatalf("❌ --to [%d] must not be lower than --from [%d]\n%s\n", *to, *from, usage)
+	}
+
+	return *from, *to
 }
@@ -0,0 +1,18 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Balance snapshot of native balance and nonce of address after block,
+// snapshot is stored only at blocks that address is touched
+type Balance struct {
+	Address     string `json:"address" bson:"address"`
+	BlockNumber uint64 `json:"blockNumber" bson:"blockNumber"`
+	BlockHash   string `json:"blockHash" bson:"blockHash"`
+	// Balance is decimal string of wei
+	Balance string `json:"balance" bson:"balance"`
+	Nonce   uint64 `json:"nonce" bson:"nonce"`
+}
+
+func (b *Balance) MarshalBson() ([]byte, error) {
+	return bson.Marshal(b)
+}
@@ -4,6 +4,10 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// BlockSchemaVersion version of data that is stored for a block, it is increased when new fields are added
+// to blocks or transactions. Blocks that have lower version will be re-indexed by `migrate` command
+const BlockSchemaVersion = 2
+
 // Block block of blockchain collection model
 type Block struct {
 	Hash                string  `json:"hash" bson:"hash"`
@@ -21,6 +25,13 @@ type Block struct {
 	TransactionRootHash string  `json:"txRootHash" bson:"txRootHash"`
 	ReceiptRootHash     string  `json:"receiptRootHash" bson:"receiptRootHash"`
 	ExtraData           []byte  `json:"extraData" bson:"extraData"`
+	// BaseFee is decimal string of base fee per gas, it is empty for blocks before London fork
+	BaseFee string `json:"baseFee,omitempty" bson:"baseFee,omitempty"`
+	MixHash string `json:"mixHash" bson:"mixHash"`
+	// TotalDifficulty is decimal string of total difficulty of chain until this block, it is empty if node doesn't return it
+	TotalDifficulty string `json:"totalDifficulty,omitempty" bson:"totalDifficulty,omitempty"`
+
+	SchemaVersion uint `json:"-" bson:"schemaVersion"`
 
 	// This is a flag that indicates that the block has been successfully fetched
 	IsDone bool `json:"-" bson:"isDone"`
@@ -8,11 +8,30 @@ import (
 // Event emitted from smart contracts to be held in this collection
 type Event struct {
 	BlockHash       string         `json:"blockHash" bson:"blockHash"`
+	BlockNumber     uint64         `json:"blockNumber" bson:"blockNumber"`
 	TransactionHash string         `json:"txHash" bson:"txHash"`
 	Index           uint           `json:"index" bson:"index"`
 	Origin          string         `json:"origin" bson:"origin"`
 	Topics          pq.StringArray `json:"topics" bson:"topics"`
 	Data            []byte         `json:"data" bson:"data"`
+	// Decoded is a form of event that decoded by registered ABI, it is nil if there are not any ABIs can decode
+	Decoded *DecodedEvent `json:"decoded,omitempty" bson:"decoded,omitempty"`
+}
+
+// DecodedEvent event name and arguments that decoded by ABI
+type DecodedEvent struct {
+	Name      string            `json:"name" bson:"name"`
+	Signature string            `json:"signature" bson:"signature"`
+	Args      []DecodedArgument `json:"args" bson:"args"`
+}
+
+// DecodedArgument named and typed argument of event,
+// value of integer is decimal string and value of address and bytes are hex string
+type DecodedArgument struct {
+	Name    string      `json:"name" bson:"name"`
+	Type    string      `json:"type" bson:"type"`
+	Indexed bool        `json:"indexed" bson:"indexed"`
+	Value   interface{} `json:"value" bson:"value"`
 }
 
 func (e *Event) MarshalBson() ([]byte, error) {
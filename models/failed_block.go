@@ -0,0 +1,19 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// FailedBlock block that has been failed to process more than maximum retries,
+// it will not be processed again until it is requeued
+type FailedBlock struct {
+	Number   uint64 `json:"number" bson:"number"`
+	Attempts int    `json:"attempts" bson:"attempts"`
+	// Stage is stage of processing that block has been failed at the last attempt
+	Stage string `json:"stage" bson:"stage"`
+	Error string `json:"error" bson:"error"`
+	// FailedAt is unix time of the last attempt
+	FailedAt int64 `json:"failedAt" bson:"failedAt"`
+}
+
+func (f *FailedBlock) MarshalBson() ([]byte, error) {
+	return bson.Marshal(f)
+}
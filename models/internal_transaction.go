@@ -0,0 +1,30 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// InternalTransaction call frame inside transaction that traced by `callTracer`,
+// top-level call of transaction is not included
+type InternalTransaction struct {
+	BlockHash       string `json:"blockHash" bson:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber" bson:"blockNumber"`
+	TransactionHash string `json:"txHash" bson:"txHash"`
+	// Index is position of call frame in transaction by depth-first order
+	Index uint `json:"index" bson:"index"`
+	// Depth is depth of call frame, top-level call of transaction is depth 0
+	Depth uint `json:"depth" bson:"depth"`
+	// Type is type of call frame e.g. CALL, DELEGATECALL, STATICCALL, CREATE, CREATE2 and SELFDESTRUCT
+	Type string `json:"type" bson:"type"`
+	From string `json:"from" bson:"from"`
+	To   string `json:"to" bson:"to"`
+	// Value is decimal string of transferred wei
+	Value   string `json:"value" bson:"value"`
+	Gas     uint64 `json:"gas" bson:"gas"`
+	GasUsed uint64 `json:"gasUsed" bson:"gasUsed"`
+	Error   string `json:"error,omitempty" bson:"error,omitempty"`
+	// Reverted is true when call frame or any of its parents is failed, its value transfer didn't take effect
+	Reverted bool `json:"reverted" bson:"reverted"`
+}
+
+func (i *InternalTransaction) MarshalBson() ([]byte, error) {
+	return bson.Marshal(i)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SinkMessage message to be published to sink, it is stored in the same transaction
+// with indexed or rolled back block so it is published at least once even if system is crashed
+type SinkMessage struct {
+	ID    string `json:"id" bson:"id"`
+	Key   string `json:"key" bson:"key"`
+	Type  string `json:"type" bson:"type"`
+	Value []byte `json:"value" bson:"value"`
+	// CreatedAt is unix time in nanoseconds, messages are published in order of created time and index
+	CreatedAt int64 `json:"createdAt" bson:"createdAt"`
+	// Index is position of message in messages that are created together
+	Index int `json:"index" bson:"index"`
+}
+
+func (s *SinkMessage) MarshalBson() ([]byte, error) {
+	return bson.Marshal(s)
+}
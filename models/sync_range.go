@@ -0,0 +1,13 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// SyncRange contiguous range of indexed block numbers, both from and to are included
+type SyncRange struct {
+	From uint64 `json:"from" bson:"from"`
+	To   uint64 `json:"to" bson:"to"`
+}
+
+func (s *SyncRange) MarshalBson() ([]byte, error) {
+	return bson.Marshal(s)
+}
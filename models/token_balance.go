@@ -0,0 +1,17 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// TokenBalance balance of ERC-20 token of holder that is maintained by token transfers
+type TokenBalance struct {
+	Token  string `json:"token" bson:"token"`
+	Holder string `json:"holder" bson:"holder"`
+	// Balance is decimal string of amount, it can be negative if transfers are not indexed from the beginning
+	Balance string `json:"balance" bson:"balance"`
+	// BlockNumber is block number that balance is changed at lastly
+	BlockNumber uint64 `json:"blockNumber" bson:"blockNumber"`
+}
+
+func (t *TokenBalance) MarshalBson() ([]byte, error) {
+	return bson.Marshal(t)
+}
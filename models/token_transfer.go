@@ -0,0 +1,34 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Token standards of token transfer
+const (
+	TokenStandardERC20   = "ERC-20"
+	TokenStandardERC721  = "ERC-721"
+	TokenStandardERC1155 = "ERC-1155"
+)
+
+// TokenTransfer transfer of ERC-20, ERC-721 and ERC-1155 token that extracted from event logs
+type TokenTransfer struct {
+	BlockHash       string `json:"blockHash" bson:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber" bson:"blockNumber"`
+	TransactionHash string `json:"txHash" bson:"txHash"`
+	LogIndex        uint   `json:"logIndex" bson:"logIndex"`
+	// BatchIndex is position of transfer in `TransferBatch` of ERC-1155, it is always 0 for other events
+	BatchIndex uint   `json:"batchIndex" bson:"batchIndex"`
+	Standard   string `json:"standard" bson:"standard"`
+	Token      string `json:"token" bson:"token"`
+	// Operator is only set by ERC-1155
+	Operator string `json:"operator,omitempty" bson:"operator,omitempty"`
+	From     string `json:"from" bson:"from"`
+	To       string `json:"to" bson:"to"`
+	// Amount is decimal string of transferred amount, it is always 1 for ERC-721
+	Amount string `json:"amount" bson:"amount"`
+	// TokenID is decimal string of token id, it is empty for ERC-20
+	TokenID string `json:"tokenId,omitempty" bson:"tokenId,omitempty"`
+}
+
+func (t *TokenTransfer) MarshalBson() ([]byte, error) {
+	return bson.Marshal(t)
+}
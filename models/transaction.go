@@ -4,18 +4,42 @@ import "go.mongodb.org/mongo-driver/bson"
 
 // Transaction blockchain transaction holder collection model
 type Transaction struct {
-	BlockHash string `json:"blockHash" bson:"blockHash"`
-	Hash      string `json:"hash" bson:"hash"`
-	From      string `json:"from" bson:"from"`
-	To        string `json:"to" bson:"to"`
-	Contract  string `json:"contract" bson:"contract"`
-	Value     string `json:"value" bson:"value"`
-	Data      []byte `json:"data" bson:"data"`
-	Gas       uint64 `json:"gas" bson:"gas"`
-	GasPrice  string `json:"gasPrice" bson:"gasPrice"`
-	Cost      string `json:"cost" bson:"cost"`
-	Nonce     uint64 `json:"nonce" bson:"nonce"`
-	State     uint64 `json:"state" bson:"state"`
+	BlockHash        string `json:"blockHash" bson:"blockHash"`
+	BlockNumber      uint64 `json:"blockNumber" bson:"blockNumber"`
+	TransactionIndex uint   `json:"transactionIndex" bson:"transactionIndex"`
+	Hash             string `json:"hash" bson:"hash"`
+	// Type is type of transaction, 0 is legacy, 1 is EIP-2930 access list and 2 is EIP-1559 dynamic fee
+	Type     uint8  `json:"type" bson:"type"`
+	ChainID  string `json:"chainId" bson:"chainId"`
+	From     string `json:"from" bson:"from"`
+	To       string `json:"to" bson:"to"`
+	Contract string `json:"contract" bson:"contract"`
+	Value    string `json:"value" bson:"value"`
+	Data     []byte `json:"data" bson:"data"`
+	Gas      uint64 `json:"gas" bson:"gas"`
+	GasPrice string `json:"gasPrice" bson:"gasPrice"`
+	// GasTipCap and GasFeeCap are the same as gas price for transactions that are not dynamic fee
+	GasTipCap  string        `json:"maxPriorityFeePerGas" bson:"gasTipCap"`
+	GasFeeCap  string        `json:"maxFeePerGas" bson:"gasFeeCap"`
+	AccessList []AccessTuple `json:"accessList" bson:"accessList"`
+	Cost       string        `json:"cost" bson:"cost"`
+	Nonce      uint64        `json:"nonce" bson:"nonce"`
+	State      uint64        `json:"state" bson:"state"`
+
+	GasUsed           uint64 `json:"gasUsed" bson:"gasUsed"`
+	CumulativeGasUsed uint64 `json:"cumulativeGasUsed" bson:"cumulativeGasUsed"`
+	// EffectiveGasPrice is gas price that is actually paid, fee of transaction is gas used * effective gas price
+	EffectiveGasPrice string `json:"effectiveGasPrice" bson:"effectiveGasPrice"`
+	// V, R and S are decimal strings of signature values
+	V string `json:"v" bson:"v"`
+	R string `json:"r" bson:"r"`
+	S string `json:"s" bson:"s"`
+}
+
+// AccessTuple element of access list of transaction
+type AccessTuple struct {
+	Address     string   `json:"address" bson:"address"`
+	StorageKeys []string `json:"storageKeys" bson:"storageKeys"`
 }
 
 func (t *Transaction) MarshalBson() ([]byte, error) {
@@ -25,6 +49,9 @@ func (t *Transaction) MarshalBson() ([]byte, error) {
 // BundledTransaction It is the aggregator of data between a transaction and
 // an event within that transaction
 type BundledTransaction struct {
-	Transaction *Transaction
-	Events      []*Event
+	Transaction    *Transaction
+	Events         []*Event
+	TokenTransfers []*TokenTransfer
+	// InternalTransactions is empty when tracing is disabled
+	InternalTransactions []*InternalTransaction
 }
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Types of webhook delivery
+const (
+	WebhookTypeTransaction = "transaction"
+	WebhookTypeEvent       = "event"
+	WebhookTypeReorg       = "reorg"
+)
+
+// States of webhook delivery
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription subscriber that receives matched events and transactions by webhook.
+//
+// Events are matched by emitting contract address and first topic, transactions are matched by from, to and
+// created contract address. Subscription that has topics receives only events
+type WebhookSubscription struct {
+	ID  string `json:"id" bson:"id"`
+	URL string `json:"url" bson:"url"`
+	// Secret is key of HMAC-SHA256 signature of payload
+	Secret    string         `json:"secret,omitempty" bson:"secret"`
+	Addresses pq.StringArray `json:"addresses" bson:"addresses"`
+	Topics    pq.StringArray `json:"topics" bson:"topics"`
+	// CreatedAt is unix time that subscription is created
+	CreatedAt int64 `json:"createdAt" bson:"createdAt"`
+}
+
+func (w *WebhookSubscription) MarshalBson() ([]byte, error) {
+	return bson.Marshal(w)
+}
+
+// WebhookDelivery payload to be sent to subscriber, it is stored in the same transaction
+// with indexed block so it is delivered at least once even if system is crashed
+type WebhookDelivery struct {
+	ID             string `json:"id" bson:"id"`
+	SubscriptionID string `json:"subscriptionId" bson:"subscriptionId"`
+	Type           string `json:"type" bson:"type"`
+	BlockNumber    uint64 `json:"blockNumber" bson:"blockNumber"`
+	BlockHash      string `json:"blockHash" bson:"blockHash"`
+	// Payload is JSON body of request
+	Payload  []byte `json:"payload" bson:"payload"`
+	State    string `json:"state" bson:"state"`
+	Attempts int    `json:"attempts" bson:"attempts"`
+	// NextAttemptAt is unix time that delivery can be sent again
+	NextAttemptAt int64  `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	LastError     string `json:"lastError" bson:"lastError"`
+	CreatedAt     int64  `json:"createdAt" bson:"createdAt"`
+	// Sequence is increasing order of deliveries, deliveries of subscription are sent in order of sequence
+	Sequence int64 `json:"sequence" bson:"sequence"`
+}
+
+func (w *WebhookDelivery) MarshalBson() ([]byte, error) {
+	return bson.Marshal(w)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type IBalancesRepository interface {
+	FindBalanceAt(ctx context.Context, address common.Address, number uint64) (*models.Balance, error)
+	AddBalances(ctx context.Context, balances []*models.Balance) error
+	DeleteAllBalancesByBlockHash(ctx context.Context, blockHash common.Hash) error
+}
+
+type BalancesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBalancesRepository(db *mongo.Database) *BalancesRepository {
+	repo := &BalancesRepository{
+		collection: db.Collection("balances"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (b *BalancesRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "address", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(-1)}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bsonx.Doc{{Key: "blockHash", Value: bsonx.Int32(1)}},
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := b.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of balances repository : %s\n", err.Error())
+	}
+}
+
+// FindBalanceAt find the latest snapshot of address at or before block number, it returns nil if not found
+func (b *BalancesRepository) FindBalanceAt(ctx context.Context, address common.Address, number uint64) (*models.Balance, error) {
+	opts := options.FindOne()
+	opts.SetSort(bson.M{
+		"blockNumber": -1,
+	})
+
+	var out *models.Balance
+	if err := b.collection.FindOne(ctx, bson.M{
+		"address":     address.Hex(),
+		"blockNumber": bson.M{"$lte": number},
+	}, opts).Decode(&out); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// AddBalances insert balances by one `InsertMany`
+func (b *BalancesRepository) AddBalances(ctx context.Context, balances []*models.Balance) error {
+	if len(balances) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(balances))
+	for n, balance := range balances {
+		payload, err := balance.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[n] = payload
+	}
+
+	_, err := b.collection.InsertMany(ctx, payloads)
+	return err
+}
+
+func (b *BalancesRepository) DeleteAllBalancesByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := b.collection.DeleteMany(ctx, bson.M{
+		"blockHash": blockHash.Hex(),
+	})
+
+	return err
+}
@@ -14,14 +14,20 @@ import (
 	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
+// ErrBlockExists is returned by AddBlock when block of the same number or hash has been stored
+var ErrBlockExists = errors.New("block has been stored")
+
 type IBlocksRepository interface {
 	FindLastestBlock(ctx context.Context) (*models.Block, error)
 	FindBlockByHash(ctx context.Context, hash common.Hash) (*models.Block, error)
 	FindBlockByNumber(ctx context.Context, number uint64) (*models.Block, error)
 	FindBlockByRange(ctx context.Context, from, to uint64) ([]models.Block, error)
+	FindBlocksByCursor(ctx context.Context, cursor uint64, limit int64) ([]models.Block, error)
+	FindOutdatedBlocks(ctx context.Context, version uint, from uint64, limit int64) ([]models.Block, error)
 	FindIncompleteBlock(ctx context.Context) ([]models.Block, error)
 	AddBlock(ctx context.Context, block *models.Block) error
 	DeleteAllIncompleteBlocks(ctx context.Context) error
+	DeleteBlocksByRange(ctx context.Context, from, to uint64) error
 	UpdateToDone(ctx context.Context, number uint64) (*models.Block, error)
 	CountBlocks(ctx context.Context) (uint64, error)
 }
@@ -49,6 +55,9 @@ func (b *BlocksRepository) createIndexes() {
 			Keys:    bsonx.Doc{{Key: "number", Value: bsonx.Int32(-1)}},
 			Options: options.Index().SetUnique(true),
 		},
+		{
+			Keys: bsonx.Doc{{Key: "schemaVersion", Value: bsonx.Int32(1)}, {Key: "number", Value: bsonx.Int32(1)}},
+		},
 	}
 	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
 	_, err := b.collection.Indexes().CreateMany(context.Background(), models, opts)
@@ -125,6 +134,56 @@ func (b *BlocksRepository) FindBlockByRange(ctx context.Context, from, to uint64
 	return out, err
 }
 
+// FindBlocksByCursor find blocks that number is lower than or equal to cursor, sorted by number descending
+func (b *BlocksRepository) FindBlocksByCursor(ctx context.Context, cursor uint64, limit int64) ([]models.Block, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"number": -1,
+	})
+	opts.SetLimit(limit)
+
+	cur, err := b.collection.Find(ctx, bson.M{
+		"number": bson.M{
+			"$lte": cursor,
+		},
+		"isDone": true,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Block
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
+// FindOutdatedBlocks find done blocks that schema version is lower than version and number is greater than
+// or equal to from, sorted by number ascending. Blocks that were stored before schema version was added don't have it
+func (b *BlocksRepository) FindOutdatedBlocks(ctx context.Context, version uint, from uint64, limit int64) ([]models.Block, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"number": 1,
+	})
+	opts.SetLimit(limit)
+
+	cur, err := b.collection.Find(ctx, bson.M{
+		"schemaVersion": bson.M{
+			"$not": bson.M{"$gte": version},
+		},
+		"number": bson.M{
+			"$gte": from,
+		},
+		"isDone": true,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Block
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
 func (b *BlocksRepository) FindIncompleteBlock(ctx context.Context) ([]models.Block, error) {
 	cursor, err := b.collection.Find(ctx, bson.M{
 		"isDone": false,
@@ -145,6 +204,9 @@ func (b *BlocksRepository) AddBlock(ctx context.Context, block *models.Block) er
 	}
 
 	_, err = b.collection.InsertOne(ctx, payload)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrBlockExists
+	}
 	if err != nil {
 		return err
 	}
@@ -159,6 +221,16 @@ func (b *BlocksRepository) DeleteAllIncompleteBlocks(ctx context.Context) error
 	return err
 }
 
+func (b *BlocksRepository) DeleteBlocksByRange(ctx context.Context, from, to uint64) error {
+	_, err := b.collection.DeleteMany(ctx, bson.M{
+		"number": bson.M{
+			"$gte": from,
+			"$lte": to,
+		},
+	})
+	return err
+}
+
 func (b *BlocksRepository) UpdateToDone(ctx context.Context, number uint64) (*models.Block, error) {
 	otps := options.FindOneAndUpdate()
 	otps.SetReturnDocument(options.After)
@@ -2,16 +2,24 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"go-evm-indexer/models"
+	"log"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
 type IEventsRepository interface {
 	FindEventsByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.Event, error)
+	FindEventsByBlockHashByCursor(ctx context.Context, blockHash common.Hash, cursor uint, limit int64) ([]models.Event, error)
+	FindEvents(ctx context.Context, filter *EventFilter) ([]models.Event, error)
 	AddEvent(ctx context.Context, event *models.Event) error
+	AddEvents(ctx context.Context, events []*models.Event) error
 	DeleteAllEventsByBlockHash(ctx context.Context, blockHash common.Hash) error
 }
 
@@ -23,10 +31,40 @@ func NewEventsRepository(db *mongo.Database) *EventsRepository {
 	repo := &EventsRepository{
 		collection: db.Collection("events"),
 	}
+	repo.createIndexes()
 
 	return repo
 }
 
+func (e *EventsRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys: bsonx.Doc{{Key: "blockHash", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "origin", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "origin", Value: bsonx.Int32(1)}, {Key: "topics.0", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		},
+	}
+	// topic of each position has own index for querying by topic without address
+	for i := 0; i < 4; i++ {
+		models = append(models, mongo.IndexModel{
+			Keys: bsonx.Doc{{Key: fmt.Sprintf("topics.%d", i), Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		})
+	}
+
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := e.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of events repository : %s\n", err.Error())
+	}
+}
+
 func (e *EventsRepository) FindEventsByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.Event, error) {
 	cursor, err := e.collection.Find(ctx, bson.M{
 		"blockHash": blockHash.Hex(),
@@ -40,6 +78,89 @@ func (e *EventsRepository) FindEventsByBlockHash(ctx context.Context, blockHash
 	return out, err
 }
 
+// FindEventsByBlockHashByCursor find events of block that index is greater than or equal to cursor, sorted by index ascending
+func (e *EventsRepository) FindEventsByBlockHashByCursor(ctx context.Context, blockHash common.Hash, cursor uint, limit int64) ([]models.Event, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"index": 1,
+	})
+	opts.SetLimit(limit)
+
+	cur, err := e.collection.Find(ctx, bson.M{
+		"blockHash": blockHash.Hex(),
+		"index": bson.M{
+			"$gte": cursor,
+		},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Event
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
+// FindEvents find events that matched with filter, sorted by block number and index ascending
+func (e *EventsRepository) FindEvents(ctx context.Context, filter *EventFilter) ([]models.Event, error) {
+	query := bson.M{}
+
+	blockNumber := bson.M{}
+	if filter.FromBlock != nil {
+		blockNumber["$gte"] = *filter.FromBlock
+	}
+	if filter.ToBlock != nil {
+		blockNumber["$lte"] = *filter.ToBlock
+	}
+	if len(blockNumber) > 0 {
+		query["blockNumber"] = blockNumber
+	}
+
+	if len(filter.Addresses) > 0 {
+		addresses := make([]string, len(filter.Addresses))
+		for i, address := range filter.Addresses {
+			addresses[i] = address.Hex()
+		}
+		query["origin"] = bson.M{"$in": addresses}
+	}
+
+	for i, topics := range filter.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		hexTopics := make([]string, len(topics))
+		for j, topic := range topics {
+			hexTopics[j] = topic.Hex()
+		}
+		query[fmt.Sprintf("topics.%d", i)] = bson.M{"$in": hexTopics}
+	}
+
+	if filter.Cursor != nil {
+		query["$or"] = bson.A{
+			bson.M{"blockNumber": bson.M{"$gt": filter.Cursor.BlockNumber}},
+			bson.M{"blockNumber": filter.Cursor.BlockNumber, "index": bson.M{"$gte": filter.Cursor.Index}},
+		}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{
+		{Key: "blockNumber", Value: 1},
+		{Key: "index", Value: 1},
+	})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	cur, err := e.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Event
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
 func (e *EventsRepository) AddEvent(ctx context.Context, event *models.Event) error {
 	payload, err := event.MarshalBson()
 	if err != nil {
@@ -54,6 +175,25 @@ func (e *EventsRepository) AddEvent(ctx context.Context, event *models.Event) er
 	return nil
 }
 
+// AddEvents insert events by one `InsertMany`
+func (e *EventsRepository) AddEvents(ctx context.Context, events []*models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(events))
+	for i, event := range events {
+		payload, err := event.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[i] = payload
+	}
+
+	_, err := e.collection.InsertMany(ctx, payloads)
+	return err
+}
+
 func (e *EventsRepository) DeleteAllEventsByBlockHash(ctx context.Context, blockHash common.Hash) error {
 	_, err := e.collection.DeleteMany(ctx, bson.M{
 		"blockHash": blockHash.Hex(),
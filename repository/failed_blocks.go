@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type IFailedBlocksRepository interface {
+	FindFailedBlocks(ctx context.Context, cursor uint64, limit int64) ([]models.FailedBlock, error)
+	FindFailedBlockByNumber(ctx context.Context, number uint64) (*models.FailedBlock, error)
+	SaveFailedBlock(ctx context.Context, block *models.FailedBlock) error
+	DeleteFailedBlock(ctx context.Context, number uint64) error
+}
+
+type FailedBlocksRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFailedBlocksRepository(db *mongo.Database) *FailedBlocksRepository {
+	repo := &FailedBlocksRepository{
+		collection: db.Collection("failed_blocks"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (f *FailedBlocksRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "number", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := f.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of failed blocks repository : %s\n", err.Error())
+	}
+}
+
+// FindFailedBlocks find failed blocks that number is greater than or equal to cursor, sorted by number ascending
+func (f *FailedBlocksRepository) FindFailedBlocks(ctx context.Context, cursor uint64, limit int64) ([]models.FailedBlock, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"number": 1,
+	})
+	opts.SetLimit(limit)
+
+	cur, err := f.collection.Find(ctx, bson.M{
+		"number": bson.M{"$gte": cursor},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.FailedBlock
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
+func (f *FailedBlocksRepository) FindFailedBlockByNumber(ctx context.Context, number uint64) (*models.FailedBlock, error) {
+	var out *models.FailedBlock
+	if err := f.collection.FindOne(ctx, bson.M{
+		"number": number,
+	}).Decode(&out); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SaveFailedBlock insert failed block or replace it if block number already exists
+func (f *FailedBlocksRepository) SaveFailedBlock(ctx context.Context, block *models.FailedBlock) error {
+	payload, err := block.MarshalBson()
+	if err != nil {
+		return err
+	}
+
+	_, err = f.collection.ReplaceOne(ctx, bson.M{
+		"number": block.Number,
+	}, bson.Raw(payload), options.Replace().SetUpsert(true))
+
+	return err
+}
+
+func (f *FailedBlocksRepository) DeleteFailedBlock(ctx context.Context, number uint64) error {
+	_, err := f.collection.DeleteOne(ctx, bson.M{
+		"number": number,
+	})
+
+	return err
+}
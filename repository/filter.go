@@ -0,0 +1,29 @@
+package repository
+
+import "github.com/ethereum/go-ethereum/common"
+
+// EventFilter filter of events query, it works like filter criteria of `eth_getLogs`
+type EventFilter struct {
+	// FromBlock and ToBlock are block number range of events, nil means unbounded
+	FromBlock *uint64
+	ToBlock   *uint64
+	// Addresses restrict matches to events created by one of these contracts
+	Addresses []common.Address
+	// Topics restrict matches to events that topic of each position is one of topics in that position,
+	// empty position matches any topic
+	//
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position AND B in second position
+	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
+	Topics [][]common.Hash
+	// Cursor is position of event that result starts from
+	Cursor *EventCursor
+	Limit  int64
+}
+
+// EventCursor position of event in chain, events are sorted by block number and index ascending
+type EventCursor struct {
+	BlockNumber uint64
+	Index       uint
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type IInternalTransactionsRepository interface {
+	FindInternalTransactionsByTransactionHash(ctx context.Context, txHash common.Hash) ([]models.InternalTransaction, error)
+	AddInternalTransactions(ctx context.Context, internalTxs []*models.InternalTransaction) error
+	DeleteAllInternalTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) error
+}
+
+type InternalTransactionsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInternalTransactionsRepository(db *mongo.Database) *InternalTransactionsRepository {
+	repo := &InternalTransactionsRepository{
+		collection: db.Collection("internal_transactions"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (i *InternalTransactionsRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys: bsonx.Doc{{Key: "blockHash", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "txHash", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "from", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "to", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}},
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := i.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of internal transactions repository : %s\n", err.Error())
+	}
+}
+
+// FindInternalTransactionsByTransactionHash find internal transactions of transaction sorted by index
+func (i *InternalTransactionsRepository) FindInternalTransactionsByTransactionHash(ctx context.Context, txHash common.Hash) ([]models.InternalTransaction, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"index": 1,
+	})
+
+	cursor, err := i.collection.Find(ctx, bson.M{
+		"txHash": txHash.Hex(),
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.InternalTransaction
+	err = cursor.All(ctx, &out)
+	return out, err
+}
+
+// AddInternalTransactions insert internal transactions by one `InsertMany`
+func (i *InternalTransactionsRepository) AddInternalTransactions(ctx context.Context, internalTxs []*models.InternalTransaction) error {
+	if len(internalTxs) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(internalTxs))
+	for n, internalTx := range internalTxs {
+		payload, err := internalTx.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[n] = payload
+	}
+
+	_, err := i.collection.InsertMany(ctx, payloads)
+	return err
+}
+
+func (i *InternalTransactionsRepository) DeleteAllInternalTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := i.collection.DeleteMany(ctx, bson.M{
+		"blockHash": blockHash.Hex(),
+	})
+
+	return err
+}
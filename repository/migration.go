@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationBatchSize number of updates that are written by one `BulkWrite`
+const migrationBatchSize = 1000
+
+// migrations of mongo database, each migration is applied only once in order.
+// New migration must be appended to the end of the list, applied migration must not be changed.
+//
+// Migration is not run in transaction because it may update whole collection,
+// it must be safe to run again when it is interrupted before its version is recorded
+var migrations = []func(ctx context.Context, db *mongo.Database) error{
+	// 1: block number of events that were stored before it was added, it is looked up by block hash
+	migrateEventsBlockNumber,
+	// 2: order of webhook deliveries, stored deliveries are ordered by time that they were created
+	migrateWebhookDeliveriesSequence,
+}
+
+// Migrate function that applies migrations that have not been applied to database
+func Migrate(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("schema_migrations")
+
+	var applied struct {
+		Version int `bson:"version"`
+	}
+	err := collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"version": -1})).Decode(&applied)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to get schema version : %s", err.Error())
+	}
+
+	for i := applied.Version; i < len(migrations); i++ {
+		if err := migrations[i](ctx, db); err != nil {
+			return fmt.Errorf("failed to apply migration [ version : %d ] : %s", i+1, err.Error())
+		}
+
+		if _, err := collection.InsertOne(ctx, bson.M{"version": i + 1}); err != nil {
+			return fmt.Errorf("failed to record migration [ version : %d ] : %s", i+1, err.Error())
+		}
+
+		log.Printf("✅ [ migration : %d ] applied\n", i+1)
+	}
+
+	return nil
+}
+
+// migrateEventsBlockNumber function that sets block number of events that don't have it from their blocks,
+// blocks are scanned only when there is any of those events
+func migrateEventsBlockNumber(ctx context.Context, db *mongo.Database) error {
+	events := db.Collection("events")
+	missing := bson.M{"blockNumber": bson.M{"$exists": false}}
+
+	n, err := events.CountDocuments(ctx, missing, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	cursor, err := db.Collection("blocks").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"hash": 1, "number": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	updates := make([]mongo.WriteModel, 0, migrationBatchSize)
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+
+		_, err := events.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+		updates = updates[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var block struct {
+			Hash   string `bson:"hash"`
+			Number uint64 `bson:"number"`
+		}
+		if err := cursor.Decode(&block); err != nil {
+			return err
+		}
+
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.M{"blockHash": block.Hash, "blockNumber": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{"blockNumber": block.Number}}),
+		)
+
+		if len(updates) == migrationBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// Events of blocks that are not stored can't be migrated, those are left without block number
+	n, err = events.CountDocuments(ctx, missing)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("⚠️ [ %d ] events are left without block number, their blocks are not found\n", n)
+	}
+
+	return nil
+}
+
+// migrateWebhookDeliveriesSequence function that sets sequence of webhook deliveries that don't have it from their creation time
+func migrateWebhookDeliveriesSequence(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("webhook_deliveries").UpdateMany(ctx,
+		bson.M{"sequence": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"sequence": bson.M{"$multiply": bson.A{"$createdAt", int64(1000000000)}}}}},
+		},
+	)
+
+	return err
+}
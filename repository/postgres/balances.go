@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-evm-indexer/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const balanceColumns = `address, block_number, block_hash, balance, nonce`
+
+type BalancesRepository struct {
+	db *sql.DB
+}
+
+func NewBalancesRepository(db *sql.DB) *BalancesRepository {
+	return &BalancesRepository{
+		db: db,
+	}
+}
+
+// FindBalanceAt find the latest snapshot of address at or before block number, it returns nil if not found
+func (b *BalancesRepository) FindBalanceAt(ctx context.Context, address common.Address, number uint64) (*models.Balance, error) {
+	var out models.Balance
+	err := conn(ctx, b.db).QueryRowContext(ctx, `SELECT `+balanceColumns+` FROM balances
+		WHERE address = $1 AND block_number <= $2 ORDER BY block_number DESC LIMIT 1`, address.Hex(), number).Scan(
+		&out.Address,
+		&out.BlockNumber,
+		&out.BlockHash,
+		&out.Balance,
+		&out.Nonce,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (b *BalancesRepository) AddBalances(ctx context.Context, balances []*models.Balance) error {
+	rows := make([][]interface{}, len(balances))
+	for n, balance := range balances {
+		rows[n] = []interface{}{
+			balance.Address,
+			balance.BlockNumber,
+			balance.BlockHash,
+			balance.Balance,
+			balance.Nonce,
+		}
+	}
+
+	return insertRows(ctx, conn(ctx, b.db), "balances", balanceColumns, rows)
+}
+
+func (b *BalancesRepository) DeleteAllBalancesByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := conn(ctx, b.db).ExecContext(ctx, `DELETE FROM balances WHERE block_hash = $1`, blockHash.Hex())
+	return err
+}
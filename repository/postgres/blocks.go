@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+)
+
+const blockColumns = `hash, number, time, parent_hash, difficulty, gas_used, gas_limit, nonce, miner, size,
+	state_root_hash, uncle_hash, tx_root_hash, receipt_root_hash, extra_data, is_done, base_fee, schema_version, mix_hash, total_difficulty`
+
+type BlocksRepository struct {
+	db *sql.DB
+}
+
+func NewBlocksRepository(db *sql.DB) *BlocksRepository {
+	return &BlocksRepository{
+		db: db,
+	}
+}
+
+func scanBlock(row scanner) (*models.Block, error) {
+	var out models.Block
+	err := row.Scan(
+		&out.Hash,
+		&out.Number,
+		&out.Time,
+		&out.ParentHash,
+		&out.Difficulty,
+		&out.GasUsed,
+		&out.GasLimit,
+		&out.Nonce,
+		&out.Miner,
+		&out.Size,
+		&out.StateRootHash,
+		&out.UncleHash,
+		&out.TransactionRootHash,
+		&out.ReceiptRootHash,
+		&out.ExtraData,
+		&out.IsDone,
+		&out.BaseFee,
+		&out.SchemaVersion,
+		&out.MixHash,
+		&out.TotalDifficulty,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (b *BlocksRepository) findOne(ctx context.Context, query string, args ...interface{}) (*models.Block, error) {
+	out, err := scanBlock(conn(ctx, b.db).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (b *BlocksRepository) findMany(ctx context.Context, query string, args ...interface{}) ([]models.Block, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Block
+	for rows.Next() {
+		block, err := scanBlock(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *block)
+	}
+
+	return out, rows.Err()
+}
+
+func (b *BlocksRepository) FindLastestBlock(ctx context.Context) (*models.Block, error) {
+	return b.findOne(ctx, `SELECT `+blockColumns+` FROM blocks ORDER BY number DESC LIMIT 1`)
+}
+
+func (b *BlocksRepository) FindBlockByNumber(ctx context.Context, number uint64) (*models.Block, error) {
+	return b.findOne(ctx, `SELECT `+blockColumns+` FROM blocks WHERE number = $1`, number)
+}
+
+func (b *BlocksRepository) FindBlockByHash(ctx context.Context, hash common.Hash) (*models.Block, error) {
+	return b.findOne(ctx, `SELECT `+blockColumns+` FROM blocks WHERE hash = $1`, hash.Hex())
+}
+
+func (b *BlocksRepository) FindBlockByRange(ctx context.Context, from, to uint64) ([]models.Block, error) {
+	return b.findMany(ctx, `SELECT `+blockColumns+` FROM blocks WHERE number >= $1 AND number <= $2 ORDER BY number ASC`, from, to)
+}
+
+// FindBlocksByCursor find blocks that number is lower than or equal to cursor, sorted by number descending
+func (b *BlocksRepository) FindBlocksByCursor(ctx context.Context, cursor uint64, limit int64) ([]models.Block, error) {
+	return b.findMany(ctx, `SELECT `+blockColumns+` FROM blocks WHERE number <= $1 AND is_done ORDER BY number DESC LIMIT $2`, cursor, limit)
+}
+
+// FindOutdatedBlocks find done blocks that schema version is lower than version and number is greater than
+// or equal to from, sorted by number ascending
+func (b *BlocksRepository) FindOutdatedBlocks(ctx context.Context, version uint, from uint64, limit int64) ([]models.Block, error) {
+	return b.findMany(ctx, `SELECT `+blockColumns+` FROM blocks WHERE schema_version < $1 AND number >= $2 AND is_done
+		ORDER BY number ASC LIMIT $3`, version, from, limit)
+}
+
+func (b *BlocksRepository) FindIncompleteBlock(ctx context.Context) ([]models.Block, error) {
+	return b.findMany(ctx, `SELECT `+blockColumns+` FROM blocks WHERE NOT is_done`)
+}
+
+func (b *BlocksRepository) AddBlock(ctx context.Context, block *models.Block) error {
+	_, err := conn(ctx, b.db).ExecContext(ctx, `INSERT INTO blocks (`+blockColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`,
+		block.Hash,
+		block.Number,
+		block.Time,
+		block.ParentHash,
+		block.Difficulty,
+		block.GasUsed,
+		block.GasLimit,
+		block.Nonce,
+		block.Miner,
+		block.Size,
+		block.StateRootHash,
+		block.UncleHash,
+		block.TransactionRootHash,
+		block.ReceiptRootHash,
+		block.ExtraData,
+		block.IsDone,
+		block.BaseFee,
+		block.SchemaVersion,
+		block.MixHash,
+		block.TotalDifficulty,
+	)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repository.ErrBlockExists
+	}
+
+	return err
+}
+
+func (b *BlocksRepository) DeleteAllIncompleteBlocks(ctx context.Context) error {
+	_, err := conn(ctx, b.db).ExecContext(ctx, `DELETE FROM blocks WHERE NOT is_done`)
+	return err
+}
+
+func (b *BlocksRepository) DeleteBlocksByRange(ctx context.Context, from, to uint64) error {
+	_, err := conn(ctx, b.db).ExecContext(ctx, `DELETE FROM blocks WHERE number >= $1 AND number <= $2`, from, to)
+	return err
+}
+
+func (b *BlocksRepository) UpdateToDone(ctx context.Context, number uint64) (*models.Block, error) {
+	return scanBlock(conn(ctx, b.db).QueryRowContext(ctx, `UPDATE blocks SET is_done = TRUE WHERE number = $1 RETURNING `+blockColumns, number))
+}
+
+func (b *BlocksRepository) CountBlocks(ctx context.Context) (uint64, error) {
+	var count uint64
+	err := conn(ctx, b.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM blocks`).Scan(&count)
+	return count, err
+}
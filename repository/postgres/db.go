@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+type txKey struct{}
+
+// executor is implemented by both *sql.DB and *sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns transaction that started by Rollback if context has it,
+// otherwise it returns database connection
+func conn(ctx context.Context, db *sql.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// maxParameters number of parameters that postgres accepts in one statement
+const maxParameters = 65535
+
+// insertRows function that inserts rows by multi-row `INSERT` statements,
+// rows are split to several statements if number of parameters exceeds limit of postgres
+func insertRows(ctx context.Context, exec executor, table string, columns string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	numColumns := len(rows[0])
+	batchSize := maxParameters / numColumns
+
+	for from := 0; from < len(rows); from += batchSize {
+		to := from + batchSize
+		if to > len(rows) {
+			to = len(rows)
+		}
+
+		var (
+			query strings.Builder
+			args  = make([]interface{}, 0, (to-from)*numColumns)
+		)
+
+		query.WriteString("INSERT INTO " + table + " (" + columns + ") VALUES ")
+		for i, row := range rows[from:to] {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+
+			query.WriteString("(")
+			for j, value := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				args = append(args, value)
+				query.WriteString("$" + strconv.Itoa(len(args)))
+			}
+			query.WriteString(")")
+		}
+
+		if _, err := exec.ExecContext(ctx, query.String(), args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+)
+
+const eventColumns = `block_hash, block_number, tx_hash, log_index, origin, topics, data, decoded`
+
+type EventsRepository struct {
+	db *sql.DB
+}
+
+func NewEventsRepository(db *sql.DB) *EventsRepository {
+	return &EventsRepository{
+		db: db,
+	}
+}
+
+func scanEvent(row scanner) (*models.Event, error) {
+	var (
+		out     models.Event
+		decoded []byte
+	)
+	err := row.Scan(
+		&out.BlockHash,
+		&out.BlockNumber,
+		&out.TransactionHash,
+		&out.Index,
+		&out.Origin,
+		&out.Topics,
+		&out.Data,
+		&decoded,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if decoded != nil {
+		if err := json.Unmarshal(decoded, &out.Decoded); err != nil {
+			return nil, err
+		}
+	}
+
+	return &out, nil
+}
+
+func (e *EventsRepository) findMany(ctx context.Context, query string, args ...interface{}) ([]models.Event, error) {
+	rows, err := conn(ctx, e.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *event)
+	}
+
+	return out, rows.Err()
+}
+
+func (e *EventsRepository) FindEventsByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.Event, error) {
+	return e.findMany(ctx, `SELECT `+eventColumns+` FROM events WHERE block_hash = $1 ORDER BY log_index ASC`, blockHash.Hex())
+}
+
+// FindEventsByBlockHashByCursor find events of block that index is greater than or equal to cursor, sorted by index ascending
+func (e *EventsRepository) FindEventsByBlockHashByCursor(ctx context.Context, blockHash common.Hash, cursor uint, limit int64) ([]models.Event, error) {
+	return e.findMany(ctx, `SELECT `+eventColumns+` FROM events WHERE block_hash = $1 AND log_index >= $2 ORDER BY log_index ASC LIMIT $3`, blockHash.Hex(), cursor, limit)
+}
+
+// FindEvents find events that matched with filter, sorted by block number and index ascending
+func (e *EventsRepository) FindEvents(ctx context.Context, filter *repository.EventFilter) ([]models.Event, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.FromBlock != nil {
+		conditions = append(conditions, "block_number >= "+arg(*filter.FromBlock))
+	}
+	if filter.ToBlock != nil {
+		conditions = append(conditions, "block_number <= "+arg(*filter.ToBlock))
+	}
+
+	if len(filter.Addresses) > 0 {
+		addresses := make([]string, len(filter.Addresses))
+		for i, address := range filter.Addresses {
+			addresses[i] = address.Hex()
+		}
+		conditions = append(conditions, "origin = ANY("+arg(pq.StringArray(addresses))+")")
+	}
+
+	for i, topics := range filter.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+
+		hexTopics := make([]string, len(topics))
+		for j, topic := range topics {
+			hexTopics[j] = topic.Hex()
+		}
+		// array of postgres starts from 1
+		conditions = append(conditions, fmt.Sprintf("topics[%d] = ANY(%s)", i+1, arg(pq.StringArray(hexTopics))))
+	}
+
+	if filter.Cursor != nil {
+		conditions = append(conditions, fmt.Sprintf("(block_number, log_index) >= (%s, %s)", arg(filter.Cursor.BlockNumber), arg(filter.Cursor.Index)))
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY block_number ASC, log_index ASC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + arg(filter.Limit)
+	}
+
+	return e.findMany(ctx, query, args...)
+}
+
+func eventValues(event *models.Event) ([]interface{}, error) {
+	var decoded []byte
+	if event.Decoded != nil {
+		var err error
+		if decoded, err = json.Marshal(event.Decoded); err != nil {
+			return nil, err
+		}
+	}
+
+	return []interface{}{
+		event.BlockHash,
+		event.BlockNumber,
+		event.TransactionHash,
+		event.Index,
+		event.Origin,
+		event.Topics,
+		event.Data,
+		decoded,
+	}, nil
+}
+
+func (e *EventsRepository) AddEvent(ctx context.Context, event *models.Event) error {
+	return e.AddEvents(ctx, []*models.Event{event})
+}
+
+// AddEvents insert events by multi-row statements
+func (e *EventsRepository) AddEvents(ctx context.Context, events []*models.Event) error {
+	rows := make([][]interface{}, len(events))
+	for i, event := range events {
+		values, err := eventValues(event)
+		if err != nil {
+			return err
+		}
+		rows[i] = values
+	}
+
+	return insertRows(ctx, conn(ctx, e.db), "events", eventColumns, rows)
+}
+
+func (e *EventsRepository) DeleteAllEventsByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := conn(ctx, e.db).ExecContext(ctx, `DELETE FROM events WHERE block_hash = $1`, blockHash.Hex())
+	return err
+}
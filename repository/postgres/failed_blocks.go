@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-evm-indexer/models"
+)
+
+const failedBlockColumns = `number, attempts, stage, error, failed_at`
+
+type FailedBlocksRepository struct {
+	db *sql.DB
+}
+
+func NewFailedBlocksRepository(db *sql.DB) *FailedBlocksRepository {
+	return &FailedBlocksRepository{
+		db: db,
+	}
+}
+
+func scanFailedBlock(row scanner) (*models.FailedBlock, error) {
+	var out models.FailedBlock
+	err := row.Scan(
+		&out.Number,
+		&out.Attempts,
+		&out.Stage,
+		&out.Error,
+		&out.FailedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// FindFailedBlocks find failed blocks that number is greater than or equal to cursor, sorted by number ascending
+func (f *FailedBlocksRepository) FindFailedBlocks(ctx context.Context, cursor uint64, limit int64) ([]models.FailedBlock, error) {
+	rows, err := conn(ctx, f.db).QueryContext(ctx, `SELECT `+failedBlockColumns+` FROM failed_blocks
+		WHERE number >= $1 ORDER BY number ASC LIMIT $2`, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.FailedBlock
+	for rows.Next() {
+		block, err := scanFailedBlock(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *block)
+	}
+
+	return out, rows.Err()
+}
+
+func (f *FailedBlocksRepository) FindFailedBlockByNumber(ctx context.Context, number uint64) (*models.FailedBlock, error) {
+	out, err := scanFailedBlock(conn(ctx, f.db).QueryRowContext(ctx, `SELECT `+failedBlockColumns+` FROM failed_blocks WHERE number = $1`, number))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SaveFailedBlock insert failed block or replace it if block number already exists
+func (f *FailedBlocksRepository) SaveFailedBlock(ctx context.Context, block *models.FailedBlock) error {
+	_, err := conn(ctx, f.db).ExecContext(ctx, `INSERT INTO failed_blocks (`+failedBlockColumns+`) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (number) DO UPDATE SET attempts = EXCLUDED.attempts, stage = EXCLUDED.stage,
+		error = EXCLUDED.error, failed_at = EXCLUDED.failed_at`,
+		block.Number, block.Attempts, block.Stage, block.Error, block.FailedAt,
+	)
+
+	return err
+}
+
+func (f *FailedBlocksRepository) DeleteFailedBlock(ctx context.Context, number uint64) error {
+	_, err := conn(ctx, f.db).ExecContext(ctx, `DELETE FROM failed_blocks WHERE number = $1`, number)
+	return err
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-evm-indexer/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const internalTransactionColumns = `block_hash, block_number, tx_hash, trace_index, depth, type, from_address, to_address,
+	value, gas, gas_used, error, reverted`
+
+type InternalTransactionsRepository struct {
+	db *sql.DB
+}
+
+func NewInternalTransactionsRepository(db *sql.DB) *InternalTransactionsRepository {
+	return &InternalTransactionsRepository{
+		db: db,
+	}
+}
+
+func scanInternalTransaction(row scanner) (*models.InternalTransaction, error) {
+	var out models.InternalTransaction
+	err := row.Scan(
+		&out.BlockHash,
+		&out.BlockNumber,
+		&out.TransactionHash,
+		&out.Index,
+		&out.Depth,
+		&out.Type,
+		&out.From,
+		&out.To,
+		&out.Value,
+		&out.Gas,
+		&out.GasUsed,
+		&out.Error,
+		&out.Reverted,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// FindInternalTransactionsByTransactionHash find internal transactions of transaction sorted by index
+func (i *InternalTransactionsRepository) FindInternalTransactionsByTransactionHash(ctx context.Context, txHash common.Hash) ([]models.InternalTransaction, error) {
+	rows, err := conn(ctx, i.db).QueryContext(ctx, `SELECT `+internalTransactionColumns+` FROM internal_transactions
+		WHERE tx_hash = $1 ORDER BY trace_index ASC`, txHash.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.InternalTransaction
+	for rows.Next() {
+		internalTx, err := scanInternalTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *internalTx)
+	}
+
+	return out, rows.Err()
+}
+
+func internalTransactionValues(internalTx *models.InternalTransaction) []interface{} {
+	return []interface{}{
+		internalTx.BlockHash,
+		internalTx.BlockNumber,
+		internalTx.TransactionHash,
+		internalTx.Index,
+		internalTx.Depth,
+		internalTx.Type,
+		internalTx.From,
+		internalTx.To,
+		internalTx.Value,
+		internalTx.Gas,
+		internalTx.GasUsed,
+		internalTx.Error,
+		internalTx.Reverted,
+	}
+}
+
+// AddInternalTransactions insert internal transactions by multi-row statements
+func (i *InternalTransactionsRepository) AddInternalTransactions(ctx context.Context, internalTxs []*models.InternalTransaction) error {
+	rows := make([][]interface{}, len(internalTxs))
+	for n, internalTx := range internalTxs {
+		rows[n] = internalTransactionValues(internalTx)
+	}
+
+	return insertRows(ctx, conn(ctx, i.db), "internal_transactions", internalTransactionColumns, rows)
+}
+
+func (i *InternalTransactionsRepository) DeleteAllInternalTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := conn(ctx, i.db).ExecContext(ctx, `DELETE FROM internal_transactions WHERE block_hash = $1`, blockHash.Hex())
+	return err
+}
@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// migrations schema of database, each migration is applied only once in order.
+// New migration must be appended to the end of the list, applied migration must not be changed
+var migrations = []string{
+	// 1: blocks, transactions and events
+	`
+	CREATE TABLE IF NOT EXISTS blocks (
+		hash              TEXT             NOT NULL,
+		number            BIGINT           NOT NULL,
+		time              BIGINT           NOT NULL,
+		parent_hash       TEXT             NOT NULL,
+		difficulty        TEXT             NOT NULL,
+		gas_used          BIGINT           NOT NULL,
+		gas_limit         BIGINT           NOT NULL,
+		nonce             TEXT             NOT NULL,
+		miner             TEXT             NOT NULL,
+		size              DOUBLE PRECISION NOT NULL,
+		state_root_hash   TEXT             NOT NULL,
+		uncle_hash        TEXT             NOT NULL,
+		tx_root_hash      TEXT             NOT NULL,
+		receipt_root_hash TEXT             NOT NULL,
+		extra_data        BYTEA,
+		is_done           BOOLEAN          NOT NULL DEFAULT FALSE
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS blocks_hash_idx ON blocks (hash);
+	CREATE UNIQUE INDEX IF NOT EXISTS blocks_number_idx ON blocks (number);
+	CREATE INDEX IF NOT EXISTS blocks_is_done_idx ON blocks (is_done);
+
+	CREATE TABLE IF NOT EXISTS transactions (
+		block_hash   TEXT   NOT NULL,
+		hash         TEXT   NOT NULL,
+		from_address TEXT   NOT NULL,
+		to_address   TEXT   NOT NULL,
+		contract     TEXT   NOT NULL,
+		value        TEXT   NOT NULL,
+		data         BYTEA,
+		gas          BIGINT NOT NULL,
+		gas_price    TEXT   NOT NULL,
+		cost         TEXT   NOT NULL,
+		nonce        BIGINT NOT NULL,
+		state        BIGINT NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS transactions_hash_idx ON transactions (hash);
+	CREATE INDEX IF NOT EXISTS transactions_block_hash_idx ON transactions (block_hash);
+
+	CREATE TABLE IF NOT EXISTS events (
+		block_hash TEXT   NOT NULL,
+		tx_hash    TEXT   NOT NULL,
+		log_index  BIGINT NOT NULL,
+		origin     TEXT   NOT NULL,
+		topics     TEXT[] NOT NULL,
+		data       BYTEA
+	);
+	CREATE INDEX IF NOT EXISTS events_block_hash_idx ON events (block_hash, log_index);
+	CREATE INDEX IF NOT EXISTS events_origin_idx ON events (origin);
+	CREATE INDEX IF NOT EXISTS events_topics_idx ON events USING GIN (topics);
+	`,
+	// 2: block number of events and indexes for querying events by filter
+	`
+	ALTER TABLE events ADD COLUMN IF NOT EXISTS block_number BIGINT NOT NULL DEFAULT 0;
+	UPDATE events SET block_number = blocks.number FROM blocks WHERE events.block_hash = blocks.hash;
+	CREATE INDEX IF NOT EXISTS events_block_number_idx ON events (block_number, log_index);
+	CREATE INDEX IF NOT EXISTS events_origin_block_number_idx ON events (origin, block_number, log_index);
+	CREATE INDEX IF NOT EXISTS events_origin_topic0_idx ON events (origin, (topics[1]), block_number, log_index);
+	CREATE INDEX IF NOT EXISTS events_topic0_idx ON events ((topics[1]), block_number, log_index);
+	CREATE INDEX IF NOT EXISTS events_topic1_idx ON events ((topics[2]), block_number, log_index);
+	CREATE INDEX IF NOT EXISTS events_topic2_idx ON events ((topics[3]), block_number, log_index);
+	CREATE INDEX IF NOT EXISTS events_topic3_idx ON events ((topics[4]), block_number, log_index);
+	`,
+	// 3: decoded form of events
+	`
+	ALTER TABLE events ADD COLUMN IF NOT EXISTS decoded JSONB;
+	`,
+	// 4: token transfers
+	`
+	CREATE TABLE IF NOT EXISTS token_transfers (
+		block_hash   TEXT   NOT NULL,
+		block_number BIGINT NOT NULL,
+		tx_hash      TEXT   NOT NULL,
+		log_index    BIGINT NOT NULL,
+		batch_index  BIGINT NOT NULL,
+		standard     TEXT   NOT NULL,
+		token        TEXT   NOT NULL,
+		operator     TEXT   NOT NULL,
+		from_address TEXT   NOT NULL,
+		to_address   TEXT   NOT NULL,
+		amount       TEXT   NOT NULL,
+		token_id     TEXT   NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS token_transfers_block_hash_idx ON token_transfers (block_hash);
+	CREATE INDEX IF NOT EXISTS token_transfers_token_idx ON token_transfers (token, block_number, log_index, batch_index);
+	CREATE INDEX IF NOT EXISTS token_transfers_from_idx ON token_transfers (from_address, block_number, log_index, batch_index);
+	CREATE INDEX IF NOT EXISTS token_transfers_to_idx ON token_transfers (to_address, block_number, log_index, batch_index);
+	`,
+	// 5: failed blocks
+	`
+	CREATE TABLE IF NOT EXISTS failed_blocks (
+		number    BIGINT NOT NULL PRIMARY KEY,
+		attempts  BIGINT NOT NULL,
+		stage     TEXT   NOT NULL,
+		error     TEXT   NOT NULL,
+		failed_at BIGINT NOT NULL
+	);
+	`,
+	// 6: internal transactions
+	`
+	CREATE TABLE IF NOT EXISTS internal_transactions (
+		block_hash   TEXT    NOT NULL,
+		block_number BIGINT  NOT NULL,
+		tx_hash      TEXT    NOT NULL,
+		trace_index  BIGINT  NOT NULL,
+		depth        BIGINT  NOT NULL,
+		type         TEXT    NOT NULL,
+		from_address TEXT    NOT NULL,
+		to_address   TEXT    NOT NULL,
+		value        TEXT    NOT NULL,
+		gas          BIGINT  NOT NULL,
+		gas_used     BIGINT  NOT NULL,
+		error        TEXT    NOT NULL,
+		reverted     BOOLEAN NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS internal_transactions_block_hash_idx ON internal_transactions (block_hash);
+	CREATE INDEX IF NOT EXISTS internal_transactions_tx_hash_idx ON internal_transactions (tx_hash, trace_index);
+	CREATE INDEX IF NOT EXISTS internal_transactions_from_idx ON internal_transactions (from_address, block_number);
+	CREATE INDEX IF NOT EXISTS internal_transactions_to_idx ON internal_transactions (to_address, block_number);
+	`,
+	// 7: EIP-1559 and typed transaction fields, blocks that were stored before have schema version 0
+	`
+	ALTER TABLE blocks
+		ADD COLUMN IF NOT EXISTS base_fee       TEXT   NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS schema_version BIGINT NOT NULL DEFAULT 0;
+	CREATE INDEX IF NOT EXISTS blocks_schema_version_idx ON blocks (schema_version, number);
+
+	ALTER TABLE transactions
+		ADD COLUMN IF NOT EXISTS block_number        BIGINT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS tx_index            BIGINT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS type                BIGINT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS chain_id            TEXT   NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS gas_tip_cap         TEXT   NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS gas_fee_cap         TEXT   NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS access_list         JSONB,
+		ADD COLUMN IF NOT EXISTS gas_used            BIGINT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS cumulative_gas_used BIGINT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS effective_gas_price TEXT   NOT NULL DEFAULT '';
+	`,
+	// 8: contiguous ranges of indexed blocks
+	`
+	CREATE TABLE IF NOT EXISTS sync_state (
+		from_number BIGINT NOT NULL PRIMARY KEY,
+		to_number   BIGINT NOT NULL
+	);
+	`,
+	// 9: webhook subscriptions and outbox of webhook deliveries
+	`
+	CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id         TEXT   NOT NULL PRIMARY KEY,
+		url        TEXT   NOT NULL,
+		secret     TEXT   NOT NULL,
+		addresses  TEXT[] NOT NULL,
+		topics     TEXT[] NOT NULL,
+		created_at BIGINT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id              TEXT   NOT NULL PRIMARY KEY,
+		subscription_id TEXT   NOT NULL,
+		type            TEXT   NOT NULL,
+		block_number    BIGINT NOT NULL,
+		block_hash      TEXT   NOT NULL,
+		payload         BYTEA  NOT NULL,
+		state           TEXT   NOT NULL,
+		attempts        BIGINT NOT NULL,
+		next_attempt_at BIGINT NOT NULL,
+		last_error      TEXT   NOT NULL,
+		created_at      BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (state, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_created_at_idx ON webhook_deliveries (state, created_at);
+	`,
+	// 10: snapshots of native balance and nonce of touched addresses
+	`
+	CREATE TABLE IF NOT EXISTS balances (
+		address      TEXT   NOT NULL,
+		block_number BIGINT NOT NULL,
+		block_hash   TEXT   NOT NULL,
+		balance      TEXT   NOT NULL,
+		nonce        BIGINT NOT NULL,
+		PRIMARY KEY (address, block_number)
+	);
+	CREATE INDEX IF NOT EXISTS balances_block_hash_idx ON balances (block_hash);
+	`,
+	// 11: ledger of ERC-20 token balances, balance can be negative if transfers are not indexed from the beginning
+	`
+	CREATE TABLE IF NOT EXISTS token_balances (
+		token        TEXT           NOT NULL,
+		holder       TEXT           NOT NULL,
+		balance      NUMERIC(78, 0) NOT NULL,
+		block_number BIGINT         NOT NULL,
+		PRIMARY KEY (token, holder)
+	);
+	CREATE INDEX IF NOT EXISTS token_balances_holder_idx ON token_balances (holder, token);
+	CREATE INDEX IF NOT EXISTS token_transfers_standard_idx ON token_transfers (standard, block_number, log_index, batch_index);
+	`,
+	// 12: fields of blocks and transactions that node returns by json-rpc, those are filled by migrate command
+	`
+	ALTER TABLE blocks
+		ADD COLUMN IF NOT EXISTS mix_hash         TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS total_difficulty TEXT NOT NULL DEFAULT '';
+
+	ALTER TABLE transactions
+		ADD COLUMN IF NOT EXISTS v TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS r TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS s TEXT NOT NULL DEFAULT '';
+	`,
+	// 13: outbox of messages to be published to sink
+	`
+	CREATE TABLE IF NOT EXISTS sink_messages (
+		id         TEXT   NOT NULL PRIMARY KEY,
+		key        TEXT   NOT NULL,
+		type       TEXT   NOT NULL,
+		value      BYTEA  NOT NULL,
+		created_at BIGINT NOT NULL,
+		index      BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS sink_messages_created_at_idx ON sink_messages (created_at, index);
+	`,
+	// 14: order of webhook deliveries, stored deliveries are ordered by time that they were created
+	`
+	ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS sequence BIGINT NOT NULL DEFAULT 0;
+	UPDATE webhook_deliveries SET sequence = created_at * 1000000000 WHERE sequence = 0;
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, state, sequence);
+	`,
+}
+
+// Migrate function that applies migrations that have not been applied to database
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table : %s", err.Error())
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to get schema version : %s", err.Error())
+	}
+
+	for i := version; i < len(migrations); i++ {
+		err := NewRollback(db).ExecTransaction(ctx, func(ctx context.Context) error {
+			if _, err := conn(ctx, db).ExecContext(ctx, migrations[i]); err != nil {
+				return err
+			}
+
+			_, err := conn(ctx, db).ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, i+1)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration [ version : %d ] : %s", i+1, err.Error())
+		}
+
+		log.Printf("✅ [ migration : %d ] applied\n", i+1)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// maxTransactionAttempts is number of attempts of transaction that is failed by serialization failure or deadlock
+const maxTransactionAttempts = 5
+
+type Rollback struct {
+	db *sql.DB
+}
+
+func NewRollback(db *sql.DB) *Rollback {
+	return &Rollback{
+		db: db,
+	}
+}
+
+// ExecTransaction creates a new transaction and handles rollback/commit based on the
+// error object returned by the `function input`, transaction is retried when it is conflicted
+// with concurrent transaction
+func (r *Rollback) ExecTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = r.execTransaction(ctx, fn)
+		if err == nil || !isConflict(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 50 * time.Millisecond):
+		}
+	}
+
+	return err
+}
+
+func (r *Rollback) execTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if fn != nil {
+		if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// isConflict function that checks error is serialization failure or deadlock, those can be succeeded by retrying
+func isConflict(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
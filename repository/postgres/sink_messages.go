@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-evm-indexer/models"
+
+	"github.com/lib/pq"
+)
+
+const sinkMessageColumns = `id, key, type, value, created_at, index`
+
+type SinkMessagesRepository struct {
+	db *sql.DB
+}
+
+func NewSinkMessagesRepository(db *sql.DB) *SinkMessagesRepository {
+	return &SinkMessagesRepository{
+		db: db,
+	}
+}
+
+// FindSinkMessages find messages that have not been published, sorted by created time and index
+func (s *SinkMessagesRepository) FindSinkMessages(ctx context.Context, limit int64) ([]models.SinkMessage, error) {
+	rows, err := conn(ctx, s.db).QueryContext(ctx, `SELECT `+sinkMessageColumns+` FROM sink_messages
+		ORDER BY created_at ASC, index ASC LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.SinkMessage
+	for rows.Next() {
+		var message models.SinkMessage
+		err := rows.Scan(
+			&message.ID,
+			&message.Key,
+			&message.Type,
+			&message.Value,
+			&message.CreatedAt,
+			&message.Index,
+		)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, message)
+	}
+
+	return out, rows.Err()
+}
+
+func (s *SinkMessagesRepository) AddSinkMessages(ctx context.Context, messages []*models.SinkMessage) error {
+	rows := make([][]interface{}, len(messages))
+	for n, message := range messages {
+		rows[n] = []interface{}{
+			message.ID,
+			message.Key,
+			message.Type,
+			message.Value,
+			message.CreatedAt,
+			message.Index,
+		}
+	}
+
+	return insertRows(ctx, conn(ctx, s.db), "sink_messages", sinkMessageColumns, rows)
+}
+
+// DeleteSinkMessages delete published messages by ids
+func (s *SinkMessagesRepository) DeleteSinkMessages(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM sink_messages WHERE id = ANY($1)`, pq.StringArray(ids))
+
+	return err
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+)
+
+type SyncStateRepository struct {
+	db *sql.DB
+}
+
+func NewSyncStateRepository(db *sql.DB) *SyncStateRepository {
+	return &SyncStateRepository{
+		db: db,
+	}
+}
+
+func (s *SyncStateRepository) find(ctx context.Context, query string, args ...interface{}) ([]models.SyncRange, error) {
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.SyncRange
+	for rows.Next() {
+		var syncRange models.SyncRange
+		if err := rows.Scan(&syncRange.From, &syncRange.To); err != nil {
+			return nil, err
+		}
+		out = append(out, syncRange)
+	}
+
+	return out, rows.Err()
+}
+
+// FindSyncRanges find all ranges sorted by from ascending, ranges are locked until the end of transaction
+// when it is called in transaction
+func (s *SyncStateRepository) FindSyncRanges(ctx context.Context) ([]models.SyncRange, error) {
+	return s.find(ctx, `SELECT from_number, to_number FROM sync_state ORDER BY from_number ASC`+forUpdate(ctx))
+}
+
+// FindSyncRangesByRange find ranges that overlap with range from and to, sorted by from ascending,
+// ranges are locked until the end of transaction when it is called in transaction
+func (s *SyncStateRepository) FindSyncRangesByRange(ctx context.Context, from, to uint64) ([]models.SyncRange, error) {
+	return s.find(ctx, `SELECT from_number, to_number FROM sync_state
+		WHERE from_number <= $1 AND to_number >= $2 ORDER BY from_number ASC`+forUpdate(ctx), to, from)
+}
+
+// forUpdate function that returns locking clause when context is of transaction, ranges that are read to be replaced
+// are locked so that concurrent transaction waits for them instead of replacing them at the same time
+func forUpdate(ctx context.Context) string {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return ` FOR UPDATE`
+	}
+
+	return ""
+}
+
+func (s *SyncStateRepository) AddSyncRange(ctx context.Context, syncRange *models.SyncRange) error {
+	_, err := conn(ctx, s.db).ExecContext(ctx, `INSERT INTO sync_state (from_number, to_number) VALUES ($1, $2)`, syncRange.From, syncRange.To)
+	return err
+}
+
+// ReplaceSyncRanges delete old ranges and insert new ranges, it returns `ErrSyncStateChanged`
+// if any old ranges doesn't exist anymore. It should be called in transaction
+func (s *SyncStateRepository) ReplaceSyncRanges(ctx context.Context, oldRanges, newRanges []models.SyncRange) error {
+	for _, syncRange := range oldRanges {
+		res, err := conn(ctx, s.db).ExecContext(ctx, `DELETE FROM sync_state WHERE from_number = $1 AND to_number = $2`, syncRange.From, syncRange.To)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if deleted != 1 {
+			return repository.ErrSyncStateChanged
+		}
+	}
+
+	for i := range newRanges {
+		if err := s.AddSyncRange(ctx, &newRanges[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-evm-indexer/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const tokenBalanceColumns = `token, holder, balance, block_number`
+
+type TokenBalancesRepository struct {
+	db *sql.DB
+}
+
+func NewTokenBalancesRepository(db *sql.DB) *TokenBalancesRepository {
+	return &TokenBalancesRepository{
+		db: db,
+	}
+}
+
+// FindTokenBalancesByHolder find token balances of holder that token is greater than cursor, sorted by token ascending
+func (t *TokenBalancesRepository) FindTokenBalancesByHolder(ctx context.Context, holder common.Address, cursor string, limit int64) ([]models.TokenBalance, error) {
+	rows, err := conn(ctx, t.db).QueryContext(ctx, `SELECT `+tokenBalanceColumns+` FROM token_balances
+		WHERE holder = $1 AND token > $2 ORDER BY token ASC LIMIT $3`, holder.Hex(), cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.TokenBalance
+	for rows.Next() {
+		var balance models.TokenBalance
+		if err := rows.Scan(&balance.Token, &balance.Holder, &balance.Balance, &balance.BlockNumber); err != nil {
+			return nil, err
+		}
+		out = append(out, balance)
+	}
+
+	return out, rows.Err()
+}
+
+// AddTokenBalanceDeltas add signed amounts of deltas to balances atomically, balance is created if it doesn't exist.
+// Deltas should be sorted to prevent deadlock between concurrent transactions.
+// Block number is never moved backwards because blocks can be processed out of order
+func (t *TokenBalancesRepository) AddTokenBalanceDeltas(ctx context.Context, deltas []*models.TokenBalance) error {
+	return t.addTokenBalanceDeltas(ctx, "token_balances", deltas)
+}
+
+func (t *TokenBalancesRepository) addTokenBalanceDeltas(ctx context.Context, table string, deltas []*models.TokenBalance) error {
+	for _, delta := range deltas {
+		_, err := conn(ctx, t.db).ExecContext(ctx, `INSERT INTO `+table+` AS b (`+tokenBalanceColumns+`) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (token, holder) DO UPDATE SET balance = b.balance + EXCLUDED.balance,
+			block_number = GREATEST(b.block_number, EXCLUDED.block_number)`,
+			delta.Token, delta.Holder, delta.Balance, delta.BlockNumber,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetStagedTokenBalances create empty staging table that has the same columns, keys and indexes as token balances
+func (t *TokenBalancesRepository) ResetStagedTokenBalances(ctx context.Context) error {
+	_, err := conn(ctx, t.db).ExecContext(ctx, `DROP TABLE IF EXISTS token_balances_rebuild;
+		CREATE TABLE token_balances_rebuild (LIKE token_balances INCLUDING ALL)`)
+	return err
+}
+
+// AddStagedTokenBalanceDeltas add signed amounts of deltas to staged balances like `AddTokenBalanceDeltas`
+func (t *TokenBalancesRepository) AddStagedTokenBalanceDeltas(ctx context.Context, deltas []*models.TokenBalance) error {
+	return t.addTokenBalanceDeltas(ctx, "token_balances_rebuild", deltas)
+}
+
+// SwapStagedTokenBalances replace token balances by staging table in one transaction
+func (t *TokenBalancesRepository) SwapStagedTokenBalances(ctx context.Context) error {
+	return NewRollback(t.db).ExecTransaction(ctx, func(ctx context.Context) error {
+		_, err := conn(ctx, t.db).ExecContext(ctx, `DROP TABLE token_balances;
+			ALTER TABLE token_balances_rebuild RENAME TO token_balances`)
+		return err
+	})
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const tokenTransferColumns = `block_hash, block_number, tx_hash, log_index, batch_index, standard, token, operator,
+	from_address, to_address, amount, token_id`
+
+type TokenTransfersRepository struct {
+	db *sql.DB
+}
+
+func NewTokenTransfersRepository(db *sql.DB) *TokenTransfersRepository {
+	return &TokenTransfersRepository{
+		db: db,
+	}
+}
+
+func scanTokenTransfer(row scanner) (*models.TokenTransfer, error) {
+	var out models.TokenTransfer
+	err := row.Scan(
+		&out.BlockHash,
+		&out.BlockNumber,
+		&out.TransactionHash,
+		&out.LogIndex,
+		&out.BatchIndex,
+		&out.Standard,
+		&out.Token,
+		&out.Operator,
+		&out.From,
+		&out.To,
+		&out.Amount,
+		&out.TokenID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// find function that query token transfers by condition, condition can use `$1` as a parameter.
+// All token transfers are returned when limit is 0
+func (t *TokenTransfersRepository) find(ctx context.Context, condition string, param interface{}, cursor *repository.TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	if cursor == nil {
+		cursor = &repository.TokenTransferCursor{}
+	}
+
+	rows, err := conn(ctx, t.db).QueryContext(ctx, `SELECT `+tokenTransferColumns+` FROM token_transfers
+		WHERE (`+condition+`) AND (block_number, log_index, batch_index) >= ($2, $3, $4)
+		ORDER BY block_number ASC, log_index ASC, batch_index ASC LIMIT $5`,
+		param, cursor.BlockNumber, cursor.LogIndex, cursor.BatchIndex, sql.NullInt64{Int64: limit, Valid: limit > 0},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.TokenTransfer
+	for rows.Next() {
+		transfer, err := scanTokenTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *transfer)
+	}
+
+	return out, rows.Err()
+}
+
+// FindTokenTransfersByHolder find token transfers that holder is sender or receiver
+func (t *TokenTransfersRepository) FindTokenTransfersByHolder(ctx context.Context, holder common.Address, cursor *repository.TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	return t.find(ctx, `from_address = $1 OR to_address = $1`, holder.Hex(), cursor, limit)
+}
+
+// FindTokenTransfersByToken find token transfers of token contract
+func (t *TokenTransfersRepository) FindTokenTransfersByToken(ctx context.Context, token common.Address, cursor *repository.TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	return t.find(ctx, `token = $1`, token.Hex(), cursor, limit)
+}
+
+// FindTokenTransfersByStandard find token transfers of token standard
+func (t *TokenTransfersRepository) FindTokenTransfersByStandard(ctx context.Context, standard string, cursor *repository.TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	return t.find(ctx, `standard = $1`, standard, cursor, limit)
+}
+
+// FindTokenTransfersByBlockHash find all token transfers of block sorted by log index and batch index
+func (t *TokenTransfersRepository) FindTokenTransfersByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.TokenTransfer, error) {
+	return t.find(ctx, `block_hash = $1`, blockHash.Hex(), nil, 0)
+}
+
+func tokenTransferValues(transfer *models.TokenTransfer) []interface{} {
+	return []interface{}{
+		transfer.BlockHash,
+		transfer.BlockNumber,
+		transfer.TransactionHash,
+		transfer.LogIndex,
+		transfer.BatchIndex,
+		transfer.Standard,
+		transfer.Token,
+		transfer.Operator,
+		transfer.From,
+		transfer.To,
+		transfer.Amount,
+		transfer.TokenID,
+	}
+}
+
+func (t *TokenTransfersRepository) AddTokenTransfer(ctx context.Context, transfer *models.TokenTransfer) error {
+	return t.AddTokenTransfers(ctx, []*models.TokenTransfer{transfer})
+}
+
+// AddTokenTransfers insert token transfers by multi-row statements
+func (t *TokenTransfersRepository) AddTokenTransfers(ctx context.Context, transfers []*models.TokenTransfer) error {
+	rows := make([][]interface{}, len(transfers))
+	for i, transfer := range transfers {
+		rows[i] = tokenTransferValues(transfer)
+	}
+
+	return insertRows(ctx, conn(ctx, t.db), "token_transfers", tokenTransferColumns, rows)
+}
+
+func (t *TokenTransfersRepository) DeleteAllTokenTransfersByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := conn(ctx, t.db).ExecContext(ctx, `DELETE FROM token_transfers WHERE block_hash = $1`, blockHash.Hex())
+	return err
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-evm-indexer/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const transactionColumns = `block_hash, hash, from_address, to_address, contract, value, data, gas, gas_price, cost, nonce, state,
+	block_number, tx_index, type, chain_id, gas_tip_cap, gas_fee_cap, access_list, gas_used, cumulative_gas_used, effective_gas_price, v, r, s`
+
+type TransactionsRepository struct {
+	db *sql.DB
+}
+
+func NewTransactionsRepository(db *sql.DB) *TransactionsRepository {
+	return &TransactionsRepository{
+		db: db,
+	}
+}
+
+func scanTransaction(row scanner) (*models.Transaction, error) {
+	var (
+		out        models.Transaction
+		accessList []byte
+	)
+	err := row.Scan(
+		&out.BlockHash,
+		&out.Hash,
+		&out.From,
+		&out.To,
+		&out.Contract,
+		&out.Value,
+		&out.Data,
+		&out.Gas,
+		&out.GasPrice,
+		&out.Cost,
+		&out.Nonce,
+		&out.State,
+		&out.BlockNumber,
+		&out.TransactionIndex,
+		&out.Type,
+		&out.ChainID,
+		&out.GasTipCap,
+		&out.GasFeeCap,
+		&accessList,
+		&out.GasUsed,
+		&out.CumulativeGasUsed,
+		&out.EffectiveGasPrice,
+		&out.V,
+		&out.R,
+		&out.S,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if accessList != nil {
+		if err := json.Unmarshal(accessList, &out.AccessList); err != nil {
+			return nil, err
+		}
+	}
+
+	return &out, nil
+}
+
+func (t *TransactionsRepository) FindTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.Transaction, error) {
+	rows, err := conn(ctx, t.db).QueryContext(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE block_hash = $1`, blockHash.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *tx)
+	}
+
+	return out, rows.Err()
+}
+
+func (t *TransactionsRepository) FindTransactionByHash(ctx context.Context, hash common.Hash) (*models.Transaction, error) {
+	out, err := scanTransaction(conn(ctx, t.db).QueryRowContext(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE hash = $1`, hash.Hex()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func transactionValues(tx *models.Transaction) ([]interface{}, error) {
+	accessList, err := json.Marshal(tx.AccessList)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{
+		tx.BlockHash,
+		tx.Hash,
+		tx.From,
+		tx.To,
+		tx.Contract,
+		tx.Value,
+		tx.Data,
+		tx.Gas,
+		tx.GasPrice,
+		tx.Cost,
+		tx.Nonce,
+		tx.State,
+		tx.BlockNumber,
+		tx.TransactionIndex,
+		tx.Type,
+		tx.ChainID,
+		tx.GasTipCap,
+		tx.GasFeeCap,
+		accessList,
+		tx.GasUsed,
+		tx.CumulativeGasUsed,
+		tx.EffectiveGasPrice,
+		tx.V,
+		tx.R,
+		tx.S,
+	}, nil
+}
+
+func (t *TransactionsRepository) AddTransaction(ctx context.Context, tx *models.Transaction) error {
+	return t.AddTransactions(ctx, []*models.Transaction{tx})
+}
+
+// AddTransactions insert transactions by multi-row statements
+func (t *TransactionsRepository) AddTransactions(ctx context.Context, txs []*models.Transaction) error {
+	rows := make([][]interface{}, len(txs))
+	for i, tx := range txs {
+		values, err := transactionValues(tx)
+		if err != nil {
+			return err
+		}
+		rows[i] = values
+	}
+
+	return insertRows(ctx, conn(ctx, t.db), "transactions", transactionColumns, rows)
+}
+
+func (t *TransactionsRepository) DeleteAllTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := conn(ctx, t.db).ExecContext(ctx, `DELETE FROM transactions WHERE block_hash = $1`, blockHash.Hex())
+	return err
+}
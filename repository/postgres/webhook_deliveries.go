@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-evm-indexer/models"
+)
+
+const webhookDeliveryColumns = `id, subscription_id, type, block_number, block_hash, payload, state, attempts, next_attempt_at, last_error, created_at, sequence`
+
+type WebhookDeliveriesRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookDeliveriesRepository(db *sql.DB) *WebhookDeliveriesRepository {
+	return &WebhookDeliveriesRepository{
+		db: db,
+	}
+}
+
+// FindPendingWebhookDeliveries find pending deliveries that can be sent at the time, sorted by sequence.
+// Delivery is not found while earlier delivery of the same subscription is waiting for next attempt
+func (w *WebhookDeliveriesRepository) FindPendingWebhookDeliveries(ctx context.Context, now int64, limit int64) ([]models.WebhookDelivery, error) {
+	rows, err := conn(ctx, w.db).QueryContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries d
+		WHERE state = $1 AND next_attempt_at <= $2 AND NOT EXISTS (
+			SELECT 1 FROM webhook_deliveries w
+			WHERE w.subscription_id = d.subscription_id AND w.state = $1 AND w.next_attempt_at > $2 AND w.sequence < d.sequence
+		)
+		ORDER BY sequence ASC LIMIT $3`,
+		models.WebhookDeliveryPending, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.Type,
+			&delivery.BlockNumber,
+			&delivery.BlockHash,
+			&delivery.Payload,
+			&delivery.State,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.Sequence,
+		)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, delivery)
+	}
+
+	return out, rows.Err()
+}
+
+func (w *WebhookDeliveriesRepository) AddWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	rows := make([][]interface{}, len(deliveries))
+	for n, delivery := range deliveries {
+		rows[n] = []interface{}{
+			delivery.ID,
+			delivery.SubscriptionID,
+			delivery.Type,
+			delivery.BlockNumber,
+			delivery.BlockHash,
+			delivery.Payload,
+			delivery.State,
+			delivery.Attempts,
+			delivery.NextAttemptAt,
+			delivery.LastError,
+			delivery.CreatedAt,
+			delivery.Sequence,
+		}
+	}
+
+	return insertRows(ctx, conn(ctx, w.db), "webhook_deliveries", webhookDeliveryColumns, rows)
+}
+
+// UpdateWebhookDelivery update state and attempts of delivery
+func (w *WebhookDeliveriesRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := conn(ctx, w.db).ExecContext(ctx, `UPDATE webhook_deliveries SET state = $2, attempts = $3, next_attempt_at = $4, last_error = $5 WHERE id = $1`,
+		delivery.ID, delivery.State, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError,
+	)
+
+	return err
+}
+
+// DeleteDeliveredWebhookDeliveries delete delivered deliveries that created before the time
+func (w *WebhookDeliveriesRepository) DeleteDeliveredWebhookDeliveries(ctx context.Context, before int64) error {
+	_, err := conn(ctx, w.db).ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE state = $1 AND created_at < $2`,
+		models.WebhookDeliveryDelivered, before,
+	)
+
+	return err
+}
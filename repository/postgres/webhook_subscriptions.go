@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-evm-indexer/models"
+)
+
+const webhookSubscriptionColumns = `id, url, secret, addresses, topics, created_at`
+
+type WebhookSubscriptionsRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookSubscriptionsRepository(db *sql.DB) *WebhookSubscriptionsRepository {
+	return &WebhookSubscriptionsRepository{
+		db: db,
+	}
+}
+
+func scanWebhookSubscription(row scanner) (*models.WebhookSubscription, error) {
+	var out models.WebhookSubscription
+	err := row.Scan(
+		&out.ID,
+		&out.URL,
+		&out.Secret,
+		&out.Addresses,
+		&out.Topics,
+		&out.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// FindWebhookSubscriptions find all subscriptions sorted by created time
+func (w *WebhookSubscriptionsRepository) FindWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	rows, err := conn(ctx, w.db).QueryContext(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *subscription)
+	}
+
+	return out, rows.Err()
+}
+
+func (w *WebhookSubscriptionsRepository) FindWebhookSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	out, err := scanWebhookSubscription(conn(ctx, w.db).QueryRowContext(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (w *WebhookSubscriptionsRepository) AddWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	_, err := conn(ctx, w.db).ExecContext(ctx, `INSERT INTO webhook_subscriptions (`+webhookSubscriptionColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		subscription.ID, subscription.URL, subscription.Secret, subscription.Addresses, subscription.Topics, subscription.CreatedAt,
+	)
+
+	return err
+}
+
+// DeleteWebhookSubscription delete subscription by id, it returns false if subscription is not found
+func (w *WebhookSubscriptionsRepository) DeleteWebhookSubscription(ctx context.Context, id string) (bool, error) {
+	res, err := conn(ctx, w.db).ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,177 @@
+// Package repositorytest provides in-memory repositories of indexed data for tests of API packages,
+// only methods of reading are implemented, other methods panic
+package repositorytest
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"go-evm-indexer/repository"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type BlocksRepo struct {
+	repository.IBlocksRepository
+	Blocks []models.Block
+}
+
+func (r *BlocksRepo) FindBlockByHash(_ context.Context, hash common.Hash) (*models.Block, error) {
+	for i := range r.Blocks {
+		if common.HexToHash(r.Blocks[i].Hash) == hash {
+			return &r.Blocks[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *BlocksRepo) FindBlockByNumber(_ context.Context, number uint64) (*models.Block, error) {
+	for i := range r.Blocks {
+		if r.Blocks[i].Number == number {
+			return &r.Blocks[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *BlocksRepo) FindLastestBlock(_ context.Context) (*models.Block, error) {
+	var latest *models.Block
+	for i := range r.Blocks {
+		if latest == nil || r.Blocks[i].Number > latest.Number {
+			latest = &r.Blocks[i]
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *BlocksRepo) FindBlockByRange(_ context.Context, from, to uint64) ([]models.Block, error) {
+	var out []models.Block
+	for _, block := range r.Blocks {
+		if block.Number >= from && block.Number <= to {
+			out = append(out, block)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Number < out[j].Number
+	})
+
+	return out, nil
+}
+
+// TransactionsRepo returns transactions of block in order of Txs, so tests can store them
+// out of order to check that they are sorted by index
+type TransactionsRepo struct {
+	repository.ITransactionsRepository
+	Txs []models.Transaction
+}
+
+func (r *TransactionsRepo) FindTransactionByHash(_ context.Context, hash common.Hash) (*models.Transaction, error) {
+	for i := range r.Txs {
+		if common.HexToHash(r.Txs[i].Hash) == hash {
+			tx := r.Txs[i]
+			return &tx, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *TransactionsRepo) FindTransactionsByBlockHash(_ context.Context, blockHash common.Hash) ([]models.Transaction, error) {
+	out := []models.Transaction{}
+	for _, tx := range r.Txs {
+		if common.HexToHash(tx.BlockHash) == blockHash {
+			out = append(out, tx)
+		}
+	}
+
+	return out, nil
+}
+
+type EventsRepo struct {
+	repository.IEventsRepository
+	Events []models.Event
+}
+
+func (r *EventsRepo) FindEventsByBlockHash(_ context.Context, blockHash common.Hash) ([]models.Event, error) {
+	out := []models.Event{}
+	for _, event := range r.Events {
+		if common.HexToHash(event.BlockHash) == blockHash {
+			out = append(out, event)
+		}
+	}
+
+	return out, nil
+}
+
+// FindEvents function that returns events matched by filter sorted by block number and index, limit is not applied
+func (r *EventsRepo) FindEvents(_ context.Context, filter *repository.EventFilter) ([]models.Event, error) {
+	out := []models.Event{}
+	for _, event := range r.Events {
+		if filter.FromBlock != nil && event.BlockNumber < *filter.FromBlock {
+			continue
+		}
+
+		if filter.ToBlock != nil && event.BlockNumber > *filter.ToBlock {
+			continue
+		}
+
+		if len(filter.Addresses) > 0 && !containsAddress(filter.Addresses, common.HexToAddress(event.Origin)) {
+			continue
+		}
+
+		if !matchTopics(filter.Topics, event.Topics) {
+			continue
+		}
+
+		out = append(out, event)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].BlockNumber != out[j].BlockNumber {
+			return out[i].BlockNumber < out[j].BlockNumber
+		}
+
+		return out[i].Index < out[j].Index
+	})
+
+	return out, nil
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchTopics(filter [][]common.Hash, topics []string) bool {
+	if len(filter) > len(topics) {
+		return false
+	}
+
+	for i, position := range filter {
+		if len(position) == 0 {
+			continue
+		}
+
+		found := false
+		for _, topic := range position {
+			if topic == common.HexToHash(topics[i]) {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
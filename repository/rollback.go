@@ -6,33 +6,38 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type Rollback struct {
+// Rollback runs function input in a transaction of database, the context passed to function input
+// must be used by repositories to run their queries in that transaction
+//
+// Transaction that is failed by transient error like write conflict with concurrent transaction is retried,
+// so function input may run more than once and errors of database must be wrapped by `%w` to be detected
+type Rollback interface {
+	ExecTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type MongoRollback struct {
 	client *mongo.Client
 }
 
-func NewRollback(client *mongo.Client) Rollback {
-	return Rollback{
+func NewRollback(client *mongo.Client) *MongoRollback {
+	return &MongoRollback{
 		client: client,
 	}
 }
 
 // ExecTransaction creates a new transaction and handles rollback/commit based on the
-// error object returned by the `function input`
-func (r *Rollback) ExecTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
-	err := r.client.UseSession(ctx, func(sc mongo.SessionContext) error {
-		if err := sc.StartTransaction(); err != nil {
-			return err
-		}
-
-		if fn != nil {
-			if err := fn(sc); err != nil {
-				sc.AbortTransaction(sc)
-				return err
+// error object returned by the `function input`, transaction is retried by driver when it is failed
+// with `TransientTransactionError` label and commit is retried when result of commit is unknown
+func (r *MongoRollback) ExecTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.client.UseSession(ctx, func(sc mongo.SessionContext) error {
+		_, err := sc.WithTransaction(sc, func(sc mongo.SessionContext) (interface{}, error) {
+			if fn == nil {
+				return nil, nil
 			}
-		}
 
-		return sc.CommitTransaction(sc)
-	})
+			return nil, fn(sc)
+		})
 
-	return err
+		return err
+	})
 }
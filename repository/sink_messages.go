@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type ISinkMessagesRepository interface {
+	FindSinkMessages(ctx context.Context, limit int64) ([]models.SinkMessage, error)
+	AddSinkMessages(ctx context.Context, messages []*models.SinkMessage) error
+	DeleteSinkMessages(ctx context.Context, ids []string) error
+}
+
+type SinkMessagesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSinkMessagesRepository(db *mongo.Database) *SinkMessagesRepository {
+	repo := &SinkMessagesRepository{
+		collection: db.Collection("sink_messages"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (s *SinkMessagesRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "id", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bsonx.Doc{{Key: "createdAt", Value: bsonx.Int32(1)}, {Key: "index", Value: bsonx.Int32(1)}},
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := s.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of sink messages repository : %s\n", err.Error())
+	}
+}
+
+// FindSinkMessages find messages that have not been published, sorted by created time and index
+func (s *SinkMessagesRepository) FindSinkMessages(ctx context.Context, limit int64) ([]models.SinkMessage, error) {
+	opts := options.Find()
+	opts.SetSort(bson.D{
+		{Key: "createdAt", Value: 1},
+		{Key: "index", Value: 1},
+	})
+	opts.SetLimit(limit)
+
+	cursor, err := s.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.SinkMessage
+	err = cursor.All(ctx, &out)
+	return out, err
+}
+
+// AddSinkMessages insert messages by one `InsertMany`
+func (s *SinkMessagesRepository) AddSinkMessages(ctx context.Context, messages []*models.SinkMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(messages))
+	for n, message := range messages {
+		payload, err := message.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[n] = payload
+	}
+
+	_, err := s.collection.InsertMany(ctx, payloads)
+	return err
+}
+
+// DeleteSinkMessages delete published messages by ids
+func (s *SinkMessagesRepository) DeleteSinkMessages(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := s.collection.DeleteMany(ctx, bson.M{
+		"id": bson.M{"$in": ids},
+	})
+
+	return err
+}
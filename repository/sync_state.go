@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// ErrSyncStateChanged is returned when ranges that are going to be replaced have been changed by others
+var ErrSyncStateChanged = errors.New("sync state has been changed")
+
+type ISyncStateRepository interface {
+	FindSyncRanges(ctx context.Context) ([]models.SyncRange, error)
+	FindSyncRangesByRange(ctx context.Context, from, to uint64) ([]models.SyncRange, error)
+	AddSyncRange(ctx context.Context, syncRange *models.SyncRange) error
+	ReplaceSyncRanges(ctx context.Context, oldRanges, newRanges []models.SyncRange) error
+}
+
+type SyncStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSyncStateRepository(db *mongo.Database) *SyncStateRepository {
+	repo := &SyncStateRepository{
+		collection: db.Collection("sync_state"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (s *SyncStateRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "from", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := s.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of sync state repository : %s\n", err.Error())
+	}
+}
+
+func (s *SyncStateRepository) find(ctx context.Context, query bson.M) ([]models.SyncRange, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"from": 1,
+	})
+
+	cur, err := s.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.SyncRange
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
+// FindSyncRanges find all ranges sorted by from ascending
+func (s *SyncStateRepository) FindSyncRanges(ctx context.Context) ([]models.SyncRange, error) {
+	return s.find(ctx, bson.M{})
+}
+
+// FindSyncRangesByRange find ranges that overlap with range from and to, sorted by from ascending
+func (s *SyncStateRepository) FindSyncRangesByRange(ctx context.Context, from, to uint64) ([]models.SyncRange, error) {
+	return s.find(ctx, bson.M{
+		"from": bson.M{"$lte": to},
+		"to":   bson.M{"$gte": from},
+	})
+}
+
+func (s *SyncStateRepository) AddSyncRange(ctx context.Context, syncRange *models.SyncRange) error {
+	payload, err := syncRange.MarshalBson()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.collection.InsertOne(ctx, payload)
+	return err
+}
+
+// ReplaceSyncRanges delete old ranges and insert new ranges, it returns `ErrSyncStateChanged`
+// if any old ranges doesn't exist anymore. It should be called in transaction
+func (s *SyncStateRepository) ReplaceSyncRanges(ctx context.Context, oldRanges, newRanges []models.SyncRange) error {
+	for _, syncRange := range oldRanges {
+		res, err := s.collection.DeleteOne(ctx, bson.M{
+			"from": syncRange.From,
+			"to":   syncRange.To,
+		})
+		if err != nil {
+			return err
+		}
+
+		if res.DeletedCount != 1 {
+			return ErrSyncStateChanged
+		}
+	}
+
+	for i := range newRanges {
+		if err := s.AddSyncRange(ctx, &newRanges[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-evm-indexer/models"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type ITokenBalancesRepository interface {
+	FindTokenBalancesByHolder(ctx context.Context, holder common.Address, cursor string, limit int64) ([]models.TokenBalance, error)
+	AddTokenBalanceDeltas(ctx context.Context, deltas []*models.TokenBalance) error
+	// Staged token balances are rebuilt aside of token balances and then replace all of them at once
+	ResetStagedTokenBalances(ctx context.Context) error
+	AddStagedTokenBalanceDeltas(ctx context.Context, deltas []*models.TokenBalance) error
+	SwapStagedTokenBalances(ctx context.Context) error
+}
+
+type TokenBalancesRepository struct {
+	collection *mongo.Collection
+	// staging is collection that token balances are rebuilt in
+	staging *mongo.Collection
+}
+
+func NewTokenBalancesRepository(db *mongo.Database) *TokenBalancesRepository {
+	repo := &TokenBalancesRepository{
+		collection: db.Collection("token_balances"),
+		staging:    db.Collection("token_balances_rebuild"),
+	}
+	if err := repo.createIndexes(context.Background(), repo.collection); err != nil {
+		log.Fatalf("❌ failed to create indexes of token balances repository : %s\n", err.Error())
+	}
+
+	return repo
+}
+
+func (t *TokenBalancesRepository) createIndexes(ctx context.Context, collection *mongo.Collection) error {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "token", Value: bsonx.Int32(1)}, {Key: "holder", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bsonx.Doc{{Key: "holder", Value: bsonx.Int32(1)}, {Key: "token", Value: bsonx.Int32(1)}},
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := collection.Indexes().CreateMany(ctx, models, opts)
+	return err
+}
+
+// FindTokenBalancesByHolder find token balances of holder that token is greater than cursor, sorted by token ascending
+func (t *TokenBalancesRepository) FindTokenBalancesByHolder(ctx context.Context, holder common.Address, cursor string, limit int64) ([]models.TokenBalance, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"token": 1,
+	})
+	opts.SetLimit(limit)
+
+	cur, err := t.collection.Find(ctx, bson.M{
+		"holder": holder.Hex(),
+		"token":  bson.M{"$gt": cursor},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.TokenBalance
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
+// AddTokenBalanceDeltas add signed amounts of deltas to balances, balance is created if it doesn't exist.
+//
+// Balance is read and replaced because amount can exceed precision of decimal of mongo, so it must be called
+// in transaction that concurrent update of the same balance will be conflicted and retried.
+// Block number is never moved backwards because blocks can be processed out of order
+func (t *TokenBalancesRepository) AddTokenBalanceDeltas(ctx context.Context, deltas []*models.TokenBalance) error {
+	return addTokenBalanceDeltas(ctx, t.collection, deltas)
+}
+
+func addTokenBalanceDeltas(ctx context.Context, collection *mongo.Collection, deltas []*models.TokenBalance) error {
+	for _, delta := range deltas {
+		amount, ok := new(big.Int).SetString(delta.Balance, 10)
+		if !ok {
+			return fmt.Errorf("invalid amount of token balance delta : %s", delta.Balance)
+		}
+
+		filter := bson.M{
+			"token":  delta.Token,
+			"holder": delta.Holder,
+		}
+
+		var current *models.TokenBalance
+		if err := collection.FindOne(ctx, filter).Decode(&current); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		number := delta.BlockNumber
+		if current != nil {
+			balance, ok := new(big.Int).SetString(current.Balance, 10)
+			if !ok {
+				return fmt.Errorf("invalid amount of token balance : %s", current.Balance)
+			}
+			amount.Add(amount, balance)
+
+			if current.BlockNumber > number {
+				number = current.BlockNumber
+			}
+		}
+
+		payload, err := (&models.TokenBalance{
+			Token:       delta.Token,
+			Holder:      delta.Holder,
+			Balance:     amount.String(),
+			BlockNumber: number,
+		}).MarshalBson()
+		if err != nil {
+			return err
+		}
+
+		if _, err := collection.ReplaceOne(ctx, filter, bson.Raw(payload), options.Replace().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetStagedTokenBalances drop staged token balances of previous rebuild and create indexes of staging collection
+func (t *TokenBalancesRepository) ResetStagedTokenBalances(ctx context.Context) error {
+	if err := t.staging.Drop(ctx); err != nil {
+		return err
+	}
+
+	return t.createIndexes(ctx, t.staging)
+}
+
+// AddStagedTokenBalanceDeltas add signed amounts of deltas to staged balances like `AddTokenBalanceDeltas`
+func (t *TokenBalancesRepository) AddStagedTokenBalanceDeltas(ctx context.Context, deltas []*models.TokenBalance) error {
+	return addTokenBalanceDeltas(ctx, t.staging, deltas)
+}
+
+// SwapStagedTokenBalances replace token balances by staged token balances with `renameCollection`,
+// it can't be run in transaction
+func (t *TokenBalancesRepository) SwapStagedTokenBalances(ctx context.Context) error {
+	db := t.collection.Database()
+
+	return db.Client().Database("admin").RunCommand(ctx, bson.D{
+		{Key: "renameCollection", Value: db.Name() + "." + t.staging.Name()},
+		{Key: "to", Value: db.Name() + "." + t.collection.Name()},
+		{Key: "dropTarget", Value: true},
+	}).Err()
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type ITokenTransfersRepository interface {
+	FindTokenTransfersByHolder(ctx context.Context, holder common.Address, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error)
+	FindTokenTransfersByToken(ctx context.Context, token common.Address, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error)
+	FindTokenTransfersByStandard(ctx context.Context, standard string, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error)
+	FindTokenTransfersByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.TokenTransfer, error)
+	AddTokenTransfer(ctx context.Context, transfer *models.TokenTransfer) error
+	AddTokenTransfers(ctx context.Context, transfers []*models.TokenTransfer) error
+	DeleteAllTokenTransfersByBlockHash(ctx context.Context, blockHash common.Hash) error
+}
+
+// TokenTransferCursor position of token transfer in chain, token transfers are sorted by
+// block number, log index and batch index ascending
+type TokenTransferCursor struct {
+	BlockNumber uint64
+	LogIndex    uint
+	BatchIndex  uint
+}
+
+type TokenTransfersRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTokenTransfersRepository(db *mongo.Database) *TokenTransfersRepository {
+	repo := &TokenTransfersRepository{
+		collection: db.Collection("token_transfers"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (t *TokenTransfersRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys: bsonx.Doc{{Key: "blockHash", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "token", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "logIndex", Value: bsonx.Int32(1)}, {Key: "batchIndex", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "standard", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "logIndex", Value: bsonx.Int32(1)}, {Key: "batchIndex", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "from", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "logIndex", Value: bsonx.Int32(1)}, {Key: "batchIndex", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "to", Value: bsonx.Int32(1)}, {Key: "blockNumber", Value: bsonx.Int32(1)}, {Key: "logIndex", Value: bsonx.Int32(1)}, {Key: "batchIndex", Value: bsonx.Int32(1)}},
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := t.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of token transfers repository : %s\n", err.Error())
+	}
+}
+
+func (t *TokenTransfersRepository) find(ctx context.Context, query bson.M, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	if cursor != nil {
+		query = bson.M{
+			"$and": bson.A{
+				query,
+				bson.M{"$or": bson.A{
+					bson.M{"blockNumber": bson.M{"$gt": cursor.BlockNumber}},
+					bson.M{"blockNumber": cursor.BlockNumber, "logIndex": bson.M{"$gt": cursor.LogIndex}},
+					bson.M{"blockNumber": cursor.BlockNumber, "logIndex": cursor.LogIndex, "batchIndex": bson.M{"$gte": cursor.BatchIndex}},
+				}},
+			},
+		}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{
+		{Key: "blockNumber", Value: 1},
+		{Key: "logIndex", Value: 1},
+		{Key: "batchIndex", Value: 1},
+	})
+	opts.SetLimit(limit)
+
+	cur, err := t.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.TokenTransfer
+	err = cur.All(ctx, &out)
+	return out, err
+}
+
+// FindTokenTransfersByHolder find token transfers that holder is sender or receiver
+func (t *TokenTransfersRepository) FindTokenTransfersByHolder(ctx context.Context, holder common.Address, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	return t.find(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"from": holder.Hex()},
+			bson.M{"to": holder.Hex()},
+		},
+	}, cursor, limit)
+}
+
+// FindTokenTransfersByToken find token transfers of token contract
+func (t *TokenTransfersRepository) FindTokenTransfersByToken(ctx context.Context, token common.Address, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	return t.find(ctx, bson.M{
+		"token": token.Hex(),
+	}, cursor, limit)
+}
+
+// FindTokenTransfersByStandard find token transfers of token standard
+func (t *TokenTransfersRepository) FindTokenTransfersByStandard(ctx context.Context, standard string, cursor *TokenTransferCursor, limit int64) ([]models.TokenTransfer, error) {
+	return t.find(ctx, bson.M{
+		"standard": standard,
+	}, cursor, limit)
+}
+
+// FindTokenTransfersByBlockHash find all token transfers of block sorted by log index and batch index
+func (t *TokenTransfersRepository) FindTokenTransfersByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.TokenTransfer, error) {
+	return t.find(ctx, bson.M{
+		"blockHash": blockHash.Hex(),
+	}, nil, 0)
+}
+
+func (t *TokenTransfersRepository) AddTokenTransfer(ctx context.Context, transfer *models.TokenTransfer) error {
+	payload, err := transfer.MarshalBson()
+	if err != nil {
+		return err
+	}
+
+	_, err = t.collection.InsertOne(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddTokenTransfers insert token transfers by one `InsertMany`
+func (t *TokenTransfersRepository) AddTokenTransfers(ctx context.Context, transfers []*models.TokenTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(transfers))
+	for i, transfer := range transfers {
+		payload, err := transfer.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[i] = payload
+	}
+
+	_, err := t.collection.InsertMany(ctx, payloads)
+	return err
+}
+
+func (t *TokenTransfersRepository) DeleteAllTokenTransfersByBlockHash(ctx context.Context, blockHash common.Hash) error {
+	_, err := t.collection.DeleteMany(ctx, bson.M{
+		"blockHash": blockHash.Hex(),
+	})
+
+	return err
+}
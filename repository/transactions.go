@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"go-evm-indexer/models"
 	"log"
 	"time"
@@ -17,6 +18,7 @@ type ITransactionsRepository interface {
 	FindTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) ([]models.Transaction, error)
 	FindTransactionByHash(ctx context.Context, hash common.Hash) (*models.Transaction, error)
 	AddTransaction(ctx context.Context, tx *models.Transaction) error
+	AddTransactions(ctx context.Context, txs []*models.Transaction) error
 	DeleteAllTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) error
 }
 
@@ -65,6 +67,10 @@ func (t *TransactionsRepository) FindTransactionByHash(ctx context.Context, hash
 	if err := t.collection.FindOne(ctx, bson.M{
 		"hash": hash.Hex(),
 	}).Decode(&out); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
 		return nil, err
 	}
 
@@ -85,6 +91,25 @@ func (t *TransactionsRepository) AddTransaction(ctx context.Context, tx *models.
 	return nil
 }
 
+// AddTransactions insert transactions by one `InsertMany`
+func (t *TransactionsRepository) AddTransactions(ctx context.Context, txs []*models.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		payload, err := tx.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[i] = payload
+	}
+
+	_, err := t.collection.InsertMany(ctx, payloads)
+	return err
+}
+
 func (t *TransactionsRepository) DeleteAllTransactionsByBlockHash(ctx context.Context, blockHash common.Hash) error {
 	_, err := t.collection.DeleteMany(ctx, bson.M{
 		"blockHash": blockHash.Hex(),
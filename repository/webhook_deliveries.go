@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type IWebhookDeliveriesRepository interface {
+	FindPendingWebhookDeliveries(ctx context.Context, now int64, limit int64) ([]models.WebhookDelivery, error)
+	AddWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	DeleteDeliveredWebhookDeliveries(ctx context.Context, before int64) error
+}
+
+type WebhookDeliveriesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookDeliveriesRepository(db *mongo.Database) *WebhookDeliveriesRepository {
+	repo := &WebhookDeliveriesRepository{
+		collection: db.Collection("webhook_deliveries"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (w *WebhookDeliveriesRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "id", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bsonx.Doc{{Key: "state", Value: bsonx.Int32(1)}, {Key: "nextAttemptAt", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "state", Value: bsonx.Int32(1)}, {Key: "createdAt", Value: bsonx.Int32(1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "subscriptionId", Value: bsonx.Int32(1)}, {Key: "state", Value: bsonx.Int32(1)}, {Key: "sequence", Value: bsonx.Int32(1)}},
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := w.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of webhook deliveries repository : %s\n", err.Error())
+	}
+}
+
+// FindPendingWebhookDeliveries find pending deliveries that can be sent at the time, sorted by sequence.
+// Delivery is not found while earlier delivery of the same subscription is waiting for next attempt
+func (w *WebhookDeliveriesRepository) FindPendingWebhookDeliveries(ctx context.Context, now int64, limit int64) ([]models.WebhookDelivery, error) {
+	// Earliest sequence of waiting deliveries of each subscription, later deliveries of those subscriptions are skipped
+	waiting, err := w.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"state":         models.WebhookDeliveryPending,
+			"nextAttemptAt": bson.M{"$gt": now},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$subscriptionId",
+			"sequence": bson.M{"$min": "$sequence"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked []struct {
+		SubscriptionID string `bson:"_id"`
+		Sequence       int64  `bson:"sequence"`
+	}
+	if err := waiting.All(ctx, &blocked); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"state":         models.WebhookDeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+
+	if len(blocked) > 0 {
+		nor := make(bson.A, len(blocked))
+		for i, subscription := range blocked {
+			nor[i] = bson.M{"subscriptionId": subscription.SubscriptionID, "sequence": bson.M{"$gt": subscription.Sequence}}
+		}
+		filter["$nor"] = nor
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "sequence", Value: 1}})
+	opts.SetLimit(limit)
+
+	cursor, err := w.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.WebhookDelivery
+	err = cursor.All(ctx, &out)
+	return out, err
+}
+
+// AddWebhookDeliveries insert deliveries by one `InsertMany`
+func (w *WebhookDeliveriesRepository) AddWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	payloads := make([]interface{}, len(deliveries))
+	for n, delivery := range deliveries {
+		payload, err := delivery.MarshalBson()
+		if err != nil {
+			return err
+		}
+		payloads[n] = payload
+	}
+
+	_, err := w.collection.InsertMany(ctx, payloads)
+	return err
+}
+
+// UpdateWebhookDelivery update state and attempts of delivery
+func (w *WebhookDeliveriesRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := w.collection.UpdateOne(ctx, bson.M{
+		"id": delivery.ID,
+	}, bson.M{
+		"$set": bson.M{
+			"state":         delivery.State,
+			"attempts":      delivery.Attempts,
+			"nextAttemptAt": delivery.NextAttemptAt,
+			"lastError":     delivery.LastError,
+		},
+	})
+
+	return err
+}
+
+// DeleteDeliveredWebhookDeliveries delete delivered deliveries that created before the time
+func (w *WebhookDeliveriesRepository) DeleteDeliveredWebhookDeliveries(ctx context.Context, before int64) error {
+	_, err := w.collection.DeleteMany(ctx, bson.M{
+		"state":     models.WebhookDeliveryDelivered,
+		"createdAt": bson.M{"$lt": before},
+	})
+
+	return err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-evm-indexer/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type IWebhookSubscriptionsRepository interface {
+	FindWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	FindWebhookSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	AddWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, id string) (bool, error)
+}
+
+type WebhookSubscriptionsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookSubscriptionsRepository(db *mongo.Database) *WebhookSubscriptionsRepository {
+	repo := &WebhookSubscriptionsRepository{
+		collection: db.Collection("webhook_subscriptions"),
+	}
+	repo.createIndexes()
+
+	return repo
+}
+
+func (w *WebhookSubscriptionsRepository) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "id", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	opts := options.CreateIndexes().SetMaxTime(10 * time.Second)
+	_, err := w.collection.Indexes().CreateMany(context.Background(), models, opts)
+	if err != nil {
+		log.Fatalf("❌ failed to create indexes of webhook subscriptions repository : %s\n", err.Error())
+	}
+}
+
+// FindWebhookSubscriptions find all subscriptions sorted by created time
+func (w *WebhookSubscriptionsRepository) FindWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{
+		"createdAt": 1,
+	})
+
+	cursor, err := w.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.WebhookSubscription
+	err = cursor.All(ctx, &out)
+	return out, err
+}
+
+func (w *WebhookSubscriptionsRepository) FindWebhookSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	var out *models.WebhookSubscription
+	if err := w.collection.FindOne(ctx, bson.M{
+		"id": id,
+	}).Decode(&out); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (w *WebhookSubscriptionsRepository) AddWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	payload, err := subscription.MarshalBson()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.collection.InsertOne(ctx, bson.Raw(payload))
+	return err
+}
+
+// DeleteWebhookSubscription delete subscription by id, it returns false if subscription is not found
+func (w *WebhookSubscriptionsRepository) DeleteWebhookSubscription(ctx context.Context, id string) (bool, error) {
+	res, err := w.collection.DeleteOne(ctx, bson.M{
+		"id": id,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount > 0, nil
+}